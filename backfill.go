@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xenowits/nakamoto-coefficient-calculator/core/chains"
+)
+
+// backfill computes the coefficients of a Cosmos SDK chain at past heights using an archive node,
+// appending one JSON line per height to the output file. Heights already in the file are skipped,
+// so an interrupted backfill can be resumed by running it again.
+func backfill(args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	chain := flags.String("chain", "", "chain token, for example ATOM")
+	rest := flags.String("rest", "", "REST API URL of an archive node of the chain")
+	heightList := flags.String("heights", "", "comma separated block heights")
+	from := flags.String("from", "", "first day to backfill, as YYYY-MM-DD (UTC)")
+	to := flags.String("to", "", "last day to backfill, as YYYY-MM-DD (UTC, default: today)")
+	out := flags.String("out", "", "JSON lines file the results are appended to (default: history/<chain>.jsonl)")
+	flags.Parse(args)
+
+	if *chain == "" || *rest == "" {
+		return errors.New("missing -chain or -rest")
+	}
+	if (*heightList == "") == (*from == "") {
+		return errors.New("expected either -heights or -from")
+	}
+
+	token := chains.Token(strings.ToUpper(*chain))
+	if *out == "" {
+		*out = filepath.Join("history", string(token)+".jsonl")
+	}
+
+	done, err := readBackfilled(*out)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*out), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(*out, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	save := func(height int64) error {
+		if done[height] {
+			log.Printf("Skipping height %d of %s: already backfilled", height, token)
+			return nil
+		}
+
+		value, err := chains.CosmosValueAt(token, *rest, height)
+		if err != nil {
+			return fmt.Errorf("height %d: %w", height, err)
+		}
+
+		line, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		done[height] = true
+
+		log.Printf("Backfilled %s at height %d (%s): %d", token, height, value.Time.Format(time.DateOnly), value.NCVal)
+		return nil
+	}
+
+	if *heightList != "" {
+		for _, s := range strings.Split(*heightList, ",") {
+			height, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil || height <= 0 {
+				return fmt.Errorf("invalid height %q", s)
+			}
+			if err := save(height); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start, err := time.Parse(time.DateOnly, *from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if *to != "" {
+		if end, err = time.Parse(time.DateOnly, *to); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		height, err := chains.CosmosHeightAt(*rest, day)
+		if err != nil {
+			return fmt.Errorf("%s: %w", day.Format(time.DateOnly), err)
+		}
+		if err := save(height); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readBackfilled returns the heights already persisted in the given file, if it exists.
+func readBackfilled(path string) (map[int64]bool, error) {
+	done := make(map[int64]bool)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var value chains.HistoricalValue
+		if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		done[value.Height] = true
+	}
+
+	return done, scanner.Err()
+}
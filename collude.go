@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// collude asks a running server to recompute the coefficients of one or more chains
+// assuming the validators of each group in the groups file collude.
+func collude(args []string) error {
+	flags := flag.NewFlagSet("collude", flag.ExitOnError)
+	server := flags.String("server", "http://localhost:8080", "URL of the running server")
+	chainList := flags.String("chains", "", "comma separated chain tokens, for example ATOM,OSMO (default: all chains)")
+	groupsFile := flags.String("groups", "", `JSON file with the collusion groups: {"groups": [{"name": "...", "validators": ["..."]}]}`)
+	flags.Parse(args)
+
+	if *groupsFile == "" {
+		return fmt.Errorf("missing -groups file")
+	}
+
+	body, err := os.ReadFile(*groupsFile)
+	if err != nil {
+		return err
+	}
+
+	var req JsonCollusionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return fmt.Errorf("failed to parse groups file: %w", err)
+	}
+	if *chainList != "" {
+		req.Chains = strings.Split(*chainList, ",")
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(strings.TrimSuffix(*server, "/")+"/naka-coeffs/collusion", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server responded with %d: %s", resp.StatusCode, respBody)
+	}
+
+	var res struct {
+		Coefficients []JsonCollusionResponse `json:"coefficients"`
+	}
+	if err := json.Unmarshal(respBody, &res); err != nil {
+		return err
+	}
+
+	for _, coeff := range res.Coefficients {
+		if coeff.Error != "" {
+			fmt.Printf("%s (%s): %s\n", coeff.ChainName, coeff.ChainToken, coeff.Error)
+			continue
+		}
+		fmt.Printf("%s (%s): %d -> %d %v\n", coeff.ChainName, coeff.ChainToken, coeff.NakaCoCurrVal, coeff.NakaCoCollusion, coeff.Matched)
+	}
+
+	return nil
+}
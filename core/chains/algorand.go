@@ -7,11 +7,10 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"net/http"
 	"sort"
 	"time"
-
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
 )
 
 type AlgorandValidator struct {
@@ -29,8 +28,23 @@ type AlgorandValidator struct {
 
 type AlgorandResponse []AlgorandValidator
 
-func Algorand() (int, error) {
-	var votingPowers []int64
+// algorandExpiryForecastDays are the horizons, in days, of the coefficients forecast assuming expiring
+// participation keys are not renewed. They are reported as the expiry_<N>d alternatives.
+var algorandExpiryForecastDays = []float64{7, 30}
+
+// algorandCertCommittee is the certification committee of the Algorand consensus protocol, whose votes
+// finalize a block (CertCommitteeSize and CertCommitteeThreshold in go-algorand).
+var algorandCertCommittee = Committee{Size: 1500, Threshold: 1112}
+
+const (
+	// algorandImminentExpiryDays is the number of days within which a participation key expiry is flagged.
+	algorandImminentExpiryDays = 7
+	// algorandLargeStakePercent is the share of the online stake above which an imminent expiry is flagged.
+	algorandLargeStakePercent = 1
+)
+
+func Algorand() (Distribution, error) {
+	var votingPowers []Validator
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
 
@@ -39,41 +53,95 @@ func Algorand() (int, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		log.Println(err)
-		return 0, errors.New("create get request for Algorand")
+		return Distribution{}, errors.New("create get request for Algorand")
 	}
 
 	resp, err := new(http.Client).Do(req)
 	if err != nil {
 		log.Println(err)
-		return 0, errors.New("get request unsuccessful")
+		return Distribution{}, errors.New("get request unsuccessful")
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 	resp.Body.Close()
 
 	var response AlgorandResponse
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	// Loop through the validators staked amounts
+	totalStake := new(big.Int)
 	for _, val := range response {
-		votingPowers = append(votingPowers, int64(val.StakeMicroAlgo))
+		votingPowers = append(votingPowers, Validator{
+			Address:     val.Address,
+			VotingPower: new(big.Int).SetUint64(val.StakeMicroAlgo),
+		})
+		totalStake.Add(totalStake, new(big.Int).SetUint64(val.StakeMicroAlgo))
+	}
+
+	return Distribution{
+		Validators:   votingPowers,
+		Alternatives: algorandExpiryForecasts(response),
+		Warnings:     algorandExpiryWarnings(response, totalStake),
+		Committee:    &algorandCertCommittee,
+	}, nil
+}
+
+// algorandExpiryForecasts returns the online stake left after each forecast horizon, assuming participation
+// keys expiring within it are not renewed. Validators whose key expires go offline and leave the online stake.
+func algorandExpiryForecasts(validators AlgorandResponse) map[string]Distribution {
+	forecasts := make(map[string]Distribution)
+	for _, days := range algorandExpiryForecastDays {
+		var remaining []Validator
+		for _, val := range validators {
+			if val.ExpiresInDays > days {
+				remaining = append(remaining, Validator{
+					Address:     val.Address,
+					VotingPower: new(big.Int).SetUint64(val.StakeMicroAlgo),
+				})
+			}
+		}
+
+		forecasts[fmt.Sprintf("expiry_%gd", days)] = Distribution{Validators: remaining}
+	}
+
+	return forecasts
+}
+
+// algorandExpiryWarnings flags the validators holding more than algorandLargeStakePercent of the online stake
+// whose participation key expires within algorandImminentExpiryDays, the largest first.
+func algorandExpiryWarnings(validators AlgorandResponse, totalStake *big.Int) []Warning {
+	if totalStake.Sign() == 0 {
+		return nil
 	}
 
-	// Sort the voting powers in descending order since they maybe in random order.
-	sort.Slice(votingPowers, func(i, j int) bool { return votingPowers[i] > votingPowers[j] })
+	sorted := make(AlgorandResponse, len(validators))
+	copy(sorted, validators)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StakeMicroAlgo > sorted[j].StakeMicroAlgo })
 
-	totalVotingPower := utils.CalculateTotalVotingPower(votingPowers)
-	fmt.Println("Total voting power:", totalVotingPower)
+	total, _ := new(big.Float).SetInt(totalStake).Float64()
 
-	// Now we're ready to calculate the nakamoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficient(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for Algorand is", nakamotoCoefficient)
+	var warnings []Warning
+	for _, val := range sorted {
+		percent := float64(val.StakeMicroAlgo) / total * 100
+		if percent <= algorandLargeStakePercent {
+			break
+		}
+		if val.ExpiresInDays > algorandImminentExpiryDays {
+			continue
+		}
+
+		warnings = append(warnings, Warning{
+			Validator: val.Address,
+			Message: fmt.Sprintf("participation key holding %.2f%% of the online stake expires in %.1f days at round %d",
+				percent, val.ExpiresInDays, val.LastVotingRound),
+		})
+	}
 
-	return nakamotoCoefficient, nil
+	return warnings
 }
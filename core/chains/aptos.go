@@ -2,14 +2,17 @@ package chains
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/big"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
@@ -17,66 +20,162 @@ import (
 
 const AptosValidatorsUrl = "https://fullnode.mainnet.aptoslabs.com/v1/accounts/0x1/resource/0x1::stake::ValidatorSet"
 
+type aptosValidatorInfo struct {
+	Addr        string `json:"addr"`
+	VotingPower string `json:"voting_power"`
+	Config      struct {
+		// NetworkAddresses is the hex of the BCS encoded network addresses of the validator.
+		NetworkAddresses string `json:"network_addresses"`
+	} `json:"config"`
+}
+
 type AptosResponse struct {
 	Data struct {
-		ActiveValidators []struct {
-			VotingPower string `json:"voting_power"`
-		} `json:"active_validators"`
-		TotalVotingPower string `json:"total_voting_power"`
+		ActiveValidators []aptosValidatorInfo `json:"active_validators"`
+		// PendingActive join and PendingInactive leave the active validators at the next epoch.
+		PendingActive    []aptosValidatorInfo `json:"pending_active"`
+		PendingInactive  []aptosValidatorInfo `json:"pending_inactive"`
+		TotalVotingPower string               `json:"total_voting_power"`
 	} `json:"data"`
 }
 
-func Aptos() (int, error) {
+func Aptos() (Distribution, error) {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, AptosValidatorsUrl, nil)
 	if err != nil {
 		log.Println(err)
-		return 0, errors.New("could not create get request for aptos")
+		return Distribution{}, errors.New("could not create get request for aptos")
 	}
 
 	resp, err := new(http.Client).Do(req)
 	if err != nil {
 		log.Println(err)
-		return 0, errors.New("get request failed for aptos")
+		return Distribution{}, errors.New("get request failed for aptos")
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	var response AptosResponse
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return 0, errors.New("could not unmarshal response for aptos")
+		return Distribution{}, errors.New("could not unmarshal response for aptos")
 	}
 
 	expectedTotalVotingPower, err := strconv.ParseInt(response.Data.TotalVotingPower, 10, 64)
 	if err != nil {
-		return 0, errors.New("failed to convert total voting power to int64")
+		return Distribution{}, errors.New("failed to convert total voting power to int64")
 	}
 
-	var votingPowers []big.Int
+	var (
+		validators       []Validator
+		votingPowers     []big.Int
+		networkAddresses = make(map[string]string)
+	)
 
 	for _, ele := range response.Data.ActiveValidators {
 		val, _ := strconv.Atoi(ele.VotingPower)
+		validators = append(validators, Validator{Address: ele.Addr, VotingPower: big.NewInt(int64(val))})
 		votingPowers = append(votingPowers, *big.NewInt(int64(val)))
+
+		if host := aptosNetworkHost(ele.Config.NetworkAddresses); host != "" {
+			networkAddresses[ele.Addr] = host
+		}
 	}
 
 	calculatedTotalVotingPower := *utils.CalculateTotalVotingPowerBigNums(votingPowers)
-	coefficient := utils.CalcNakamotoCoefficientBigNums(&calculatedTotalVotingPower, votingPowers)
 
 	if expectedTotalVotingPower != calculatedTotalVotingPower.Int64() {
 		fmt.Printf("Expected total voting power: %d\n", expectedTotalVotingPower)
 		fmt.Printf("Calculated total voting power: %s\n", calculatedTotalVotingPower.String())
-		return 0, fmt.Errorf("total voting power mismatch: expected %d != calculated %s", expectedTotalVotingPower, calculatedTotalVotingPower.String())
+		return Distribution{}, fmt.Errorf("total voting power mismatch: expected %d != calculated %s", expectedTotalVotingPower, calculatedTotalVotingPower.String())
 	}
 
 	fmt.Printf("Total voting power: %s\n", calculatedTotalVotingPower.String())
-	fmt.Printf("The Nakomoto coefficient for Aptos is %d\n", coefficient)
 
-	return coefficient, nil
+	return Distribution{
+		Validators:       validators,
+		NextEpoch:        aptosNextEpoch(validators, response.Data.PendingActive, response.Data.PendingInactive),
+		NetworkAddresses: networkAddresses,
+	}, nil
+}
+
+// aptosNextEpoch returns the active validators of the next epoch, or nil if no validator joins or leaves.
+// Leaving validators are moved from the active validators to pending_inactive right away, so the next set is
+// the active validators and the joining ones. Validators keep their current voting power, since stake added or
+// unlocked within a pool during the epoch is not part of the validator set.
+func aptosNextEpoch(active []Validator, pendingActive, pendingInactive []aptosValidatorInfo) []Validator {
+	if len(pendingActive) == 0 && len(pendingInactive) == 0 {
+		return nil
+	}
+
+	next := append([]Validator(nil), active...)
+	for _, ele := range pendingActive {
+		val, _ := strconv.Atoi(ele.VotingPower)
+		next = append(next, Validator{Address: ele.Addr, VotingPower: big.NewInt(int64(val))})
+	}
+
+	return next
+}
+
+// aptosNetworkHost returns the host of the first network address of a validator. The addresses are a BCS
+// encoded list of network addresses, each itself BCS encoded bytes of a list of protocols such as
+// Dns("example.com") or Ip4(192.0.2.1), followed by Tcp and the Noise and Handshake protocols.
+func aptosNetworkHost(encoded string) string {
+	b, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil {
+		return ""
+	}
+
+	// Number of addresses, byte length of the first address, number of its protocols.
+	for i := 0; i < 3; i++ {
+		n, read := uleb128(b)
+		if read == 0 || n == 0 {
+			return ""
+		}
+		b = b[read:]
+	}
+	if len(b) == 0 {
+		return ""
+	}
+
+	protocol, b := b[0], b[1:]
+	switch protocol {
+	case 0: // Ip4
+		if len(b) < net.IPv4len {
+			return ""
+		}
+		return net.IP(b[:net.IPv4len]).String()
+	case 1: // Ip6
+		if len(b) < net.IPv6len {
+			return ""
+		}
+		return net.IP(b[:net.IPv6len]).String()
+	case 2, 3, 4: // Dns, Dns4, Dns6
+		n, read := uleb128(b)
+		if read == 0 || uint64(len(b)-read) < n {
+			return ""
+		}
+		return string(b[read : read+int(n)])
+	default:
+		return ""
+	}
+}
+
+// uleb128 decodes an unsigned LEB128 integer and returns it with the number of bytes read, or zero if invalid.
+func uleb128(b []byte) (uint64, int) {
+	var n uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		n |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return n, i + 1
+		}
+	}
+
+	return 0, 0
 }
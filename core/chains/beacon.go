@@ -0,0 +1,291 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// beaconTimeout is the timeout of the validators request, whose response lists every validator of the chain.
+const beaconTimeout = 5 * time.Minute
+
+type beaconValidatorsResponse struct {
+	Data []struct {
+		Index     string `json:"index"`
+		Status    string `json:"status"`
+		Validator struct {
+			Pubkey                string `json:"pubkey"`
+			WithdrawalCredentials string `json:"withdrawal_credentials"`
+			EffectiveBalance      string `json:"effective_balance"`
+		} `json:"validator"`
+	} `json:"data"`
+}
+
+type beaconHeaderResponse struct {
+	Data struct {
+		Header struct {
+			Message struct {
+				Slot string `json:"slot"`
+			} `json:"message"`
+		} `json:"header"`
+	} `json:"data"`
+}
+
+type beaconBlockResponse struct {
+	Data struct {
+		Message struct {
+			ProposerIndex string `json:"proposer_index"`
+			Body          struct {
+				ExecutionPayload struct {
+					FeeRecipient string `json:"fee_recipient"`
+				} `json:"execution_payload"`
+			} `json:"body"`
+		} `json:"message"`
+	} `json:"data"`
+}
+
+// beaconURL returns the beacon API endpoint of the chain from BEACON_URL_<TOKEN>, or defaultURL.
+func beaconURL(token Token, defaultURL string) string {
+	if url := os.Getenv("BEACON_URL_" + string(token)); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+
+	return defaultURL
+}
+
+// FetchBeaconValidators returns the effective balances of the active validators from the standard beacon API
+// of a consensus client. Validators are grouped into operators when they share withdrawal credentials, a fee
+// recipient of the blocks proposed in the last FEE_RECIPIENT_SLOTS_<TOKEN> slots, or a label. Labels map
+// validator pubkeys, withdrawal addresses or fee recipients to operators; they are read from the chain's entity
+// mapping and from the given labels, which take precedence.
+func FetchBeaconValidators(token Token, beaconURL string, labels EntityMapping) (Distribution, error) {
+	response, err := fetchBeaconValidators(beaconURL + "/eth/v1/beacon/states/head/validators?status=active")
+	if err != nil {
+		return Distribution{}, err
+	}
+	if len(response.Data) == 0 {
+		return Distribution{}, errors.New("no active validators")
+	}
+
+	allLabels := make(EntityMapping)
+	if source := entityMappingSource(token); source != "" {
+		loaded, err := LoadEntityMapping(source)
+		if err != nil {
+			log.Printf("Failed to load labels of %s: %v", token.ChainName(), err)
+		}
+		for key, label := range loaded {
+			allLabels[strings.ToLower(key)] = label
+		}
+	}
+	for key, label := range labels {
+		allLabels[strings.ToLower(key)] = label
+	}
+
+	feeRecipients, err := fetchBeaconFeeRecipients(token, beaconURL)
+	if err != nil {
+		log.Printf("Failed to fetch fee recipients of %s: %v", token.ChainName(), err)
+	}
+
+	var (
+		votingPowers []Validator
+		keys         = make([][]string, len(response.Data))
+	)
+	for i, v := range response.Data {
+		balance, ok := new(big.Int).SetString(v.Validator.EffectiveBalance, 10)
+		if !ok {
+			return Distribution{}, fmt.Errorf("failed to parse effective balance %q of validator %s", v.Validator.EffectiveBalance, v.Index)
+		}
+		votingPowers = append(votingPowers, Validator{Address: v.Validator.Pubkey, VotingPower: balance})
+
+		var ids []string
+		credentials := strings.ToLower(v.Validator.WithdrawalCredentials)
+		if withdrawal := withdrawalAddress(credentials); withdrawal != "" {
+			keys[i] = append(keys[i], "withdrawal_address="+withdrawal)
+			ids = append(ids, withdrawal)
+		} else if credentials != "" {
+			keys[i] = append(keys[i], "withdrawal_credentials="+credentials)
+		}
+		if feeRecipient := feeRecipients[v.Index]; feeRecipient != "" {
+			keys[i] = append(keys[i], "fee_recipient="+feeRecipient)
+			ids = append(ids, feeRecipient)
+		}
+
+		ids = append(ids, strings.ToLower(v.Validator.Pubkey))
+		for _, id := range ids {
+			if label, ok := allLabels[id]; ok {
+				keys[i] = append(keys[i], "label="+label)
+			}
+		}
+	}
+
+	var (
+		groups []EntityGroup
+		names  = make(map[string]bool)
+	)
+	for _, linked := range linkByKeys(keys) {
+		name := beaconEntityName(keys, linked.Members)
+		if names[name] {
+			name = fmt.Sprintf("%s (%s)", name, votingPowers[linked.Members[0]].Address)
+		}
+		names[name] = true
+
+		group := EntityGroup{Entity: name, Evidence: linked.Evidence}
+		for _, i := range linked.Members {
+			group.Validators = append(group.Validators, votingPowers[i].Address)
+		}
+
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Entity < groups[j].Entity })
+
+	log.Printf("Fetched %d active validators of %s in %d operator groups", len(votingPowers), token.ChainName(), len(groups))
+
+	return Distribution{Validators: votingPowers, EntityGroups: groups}, nil
+}
+
+// withdrawalAddress returns the execution address of 0x01 and 0x02 withdrawal credentials,
+// which is the last 20 bytes of the credentials. It is empty for BLS (0x00) credentials.
+func withdrawalAddress(credentials string) string {
+	if len(credentials) != 66 || (!strings.HasPrefix(credentials, "0x01") && !strings.HasPrefix(credentials, "0x02")) {
+		return ""
+	}
+
+	return "0x" + credentials[26:]
+}
+
+// beaconEntityName names a group after its most common label, or else after its most shared key.
+func beaconEntityName(keys [][]string, members []int) string {
+	counts := make(map[string]int)
+	for _, i := range members {
+		for _, key := range keys[i] {
+			counts[key]++
+		}
+	}
+
+	var best string
+	for key, n := range counts {
+		isLabel, bestIsLabel := strings.HasPrefix(key, "label="), strings.HasPrefix(best, "label=")
+		switch {
+		case best == "",
+			isLabel && !bestIsLabel,
+			isLabel == bestIsLabel && (n > counts[best] || n == counts[best] && key < best):
+			best = key
+		}
+	}
+
+	_, name, _ := strings.Cut(best, "=")
+
+	return name
+}
+
+// fetchBeaconFeeRecipients returns the fee recipient of the validators that proposed one of the blocks in the
+// last FEE_RECIPIENT_SLOTS_<TOKEN> slots, by validator index. Recipients listed in IGNORED_FEE_RECIPIENTS_<TOKEN>,
+// such as block builders collecting the fees of the blocks they build, are left out.
+func fetchBeaconFeeRecipients(token Token, beaconURL string) (map[string]string, error) {
+	slots, _ := strconv.Atoi(os.Getenv("FEE_RECIPIENT_SLOTS_" + string(token)))
+	if slots <= 0 {
+		return nil, nil
+	}
+
+	ignored := make(map[string]bool)
+	for _, addr := range strings.Split(os.Getenv("IGNORED_FEE_RECIPIENTS_"+string(token)), ",") {
+		ignored[strings.ToLower(strings.TrimSpace(addr))] = true
+	}
+
+	var header beaconHeaderResponse
+	if err := beaconGet(beaconURL+"/eth/v1/beacon/headers/head", &header); err != nil {
+		return nil, err
+	}
+	head, err := strconv.Atoi(header.Data.Header.Message.Slot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse head slot %q", header.Data.Header.Message.Slot)
+	}
+
+	feeRecipients := make(map[string]string)
+	for slot := head; slot > head-slots && slot >= 0; slot-- {
+		var block beaconBlockResponse
+		if err := beaconGet(fmt.Sprintf("%s/eth/v2/beacon/blocks/%d", beaconURL, slot), &block); err != nil {
+			// Missed slots have no block.
+			continue
+		}
+
+		feeRecipient := strings.ToLower(block.Data.Message.Body.ExecutionPayload.FeeRecipient)
+		if feeRecipient == "" || ignored[feeRecipient] {
+			continue
+		}
+		feeRecipients[block.Data.Message.ProposerIndex] = feeRecipient
+	}
+
+	return feeRecipients, nil
+}
+
+func fetchBeaconValidators(url string) (beaconValidatorsResponse, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), beaconTimeout)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Println(err)
+		return beaconValidatorsResponse{}, errors.New("create get request for beacon validators")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return beaconValidatorsResponse{}, errors.New("get request unsuccessful for beacon validators")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return beaconValidatorsResponse{}, fmt.Errorf("beacon validators request failed: %d", resp.StatusCode)
+	}
+
+	// The response is decoded as it is read since it can be hundreds of megabytes.
+	var response beaconValidatorsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return beaconValidatorsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func beaconGet(url string, response interface{}) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Println(err)
+		return errors.New("create get request for beacon api")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return errors.New("get request unsuccessful for beacon api")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("beacon api request failed: %d", resp.StatusCode)
+	}
+
+	return json.Unmarshal(body, response)
+}
@@ -1,103 +1,142 @@
 package chains
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math/big"
-	"net/http"
+)
 
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
+const (
+	// bscRPCURL is a public BSC JSON-RPC endpoint used when EVM_RPC_BNB is not set.
+	bscRPCURL = "https://bsc-dataseed.bnbchain.org"
+	// bscStakeHub is the system contract managing the BSC validators and their stake.
+	bscStakeHub = "0x0000000000000000000000000000000000002002"
+	// bscValidatorSet is the system contract tracking the validators elected to produce blocks.
+	bscValidatorSet = "0x0000000000000000000000000000000000001000"
+	// bscPageLimit is the number of validators requested per getValidators call.
+	bscPageLimit = 100
 )
 
-type Request struct {
-	height   int
-	page     int
-	per_page int
-}
+// BSC returns the stake of the BSC validators producing blocks in the current epoch, read from the
+// StakeHub and BSCValidatorSet system contracts. The stake of a validator is the BNB pooled in its
+// StakeCredit contract. All registered validators are returned as candidates.
+func BSC() (Distribution, error) {
+	rpcURL := evmRPCURL(BNB, bscRPCURL)
+	block, err := evmLatestBlock(rpcURL)
+	if err != nil {
+		return Distribution{}, err
+	}
 
-type BscResponse struct {
-	Code int `json:"code"`
-	Data struct {
-		Total      int `json:"total"`
-		Validators []struct {
-			OperatorAddress string `json:"operatorAddress"`
-			Moniker         string `json:"moniker"`
-			TotalStaked     string `json:"totalStaked"`
-		} `json:"validators"`
-	} `json:"data"`
-}
+	stakeHub := evmContract{rpcURL: rpcURL, block: block, address: bscStakeHub}
 
-type BscErrorResponse struct {
-	Id      int    `json:"id"`
-	Jsonrpc string `json:"jsonrpc"`
-	Error   string `json:"error"`
-}
-
-// https://api.bnbchain.org/bnb-staking/v1/validator/all?limit=100&offset=0
-func BSC() (int, error) {
-	totalVotingPower := int64(0)
-	votingPowers := make([]int64, 0, 200)
-	pageLimit, pageOffset := 50, 0
-	url := ""
-	for true {
-		url = fmt.Sprintf("https://api.bnbchain.org/bnb-staking/v1/validator/all?limit=%d&offset=%d", pageLimit, pageOffset)
-		resp, err := http.Get(url)
+	var (
+		votingPowers []Validator
+		total        int64
+	)
+	for offset := int64(0); offset == 0 || offset < total; offset += bscPageLimit {
+		res, err := stakeHub.call("getValidators(uint256,uint256)", abiUint(big.NewInt(offset)), abiUint(big.NewInt(bscPageLimit)))
 		if err != nil {
-			errBody, _ := ioutil.ReadAll(resp.Body)
-			var errResp BscErrorResponse
-			json.Unmarshal(errBody, &errResp)
-			log.Println(errResp.Error)
-			return 0, err
+			return Distribution{}, err
 		}
-		defer resp.Body.Close()
 
-		body, err := ioutil.ReadAll(resp.Body)
+		operators, err := abiAddressArrayAt(res, 0)
 		if err != nil {
-			return 0, err
+			return Distribution{}, fmt.Errorf("failed to decode operator addresses: %w", err)
 		}
-
-		var response BscResponse
-		err = json.Unmarshal(body, &response)
+		credits, err := abiAddressArrayAt(res, 1)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to decode credit addresses: %w", err)
+		}
+		totalLength, err := abiUintAt(res, 2)
 		if err != nil {
-			return 0, err
+			return Distribution{}, err
 		}
+		total = totalLength.Int64()
 
-		// break if no more entries left
-		if len(response.Data.Validators) == 0 {
+		if len(operators) != len(credits) {
+			return Distribution{}, fmt.Errorf("got %d operators but %d credit contracts", len(operators), len(credits))
+		}
+		if len(operators) == 0 {
 			break
 		}
 
-		// loop through the validators voting power proportions
-		for _, ele := range response.Data.Validators {
-			totalStaked := ele.TotalStaked
-			wei, _ := new(big.Int).SetString(totalStaked, 10)
-			votingPowers = append(votingPowers, weiToEther(wei))
-			totalVotingPower += weiToEther(wei)
+		for i, operator := range operators {
+			credit := evmContract{rpcURL: rpcURL, block: block, address: credits[i]}
+			res, err := credit.call("totalPooledBNB()")
+			if err != nil {
+				return Distribution{}, err
+			}
+
+			pooled, err := abiUintAt(res, 0)
+			if err != nil {
+				return Distribution{}, fmt.Errorf("failed to decode pooled BNB of %s: %w", operator, err)
+			}
+
+			votingPowers = append(votingPowers, Validator{Address: operator, VotingPower: pooled})
 		}
+	}
+
+	if int64(len(votingPowers)) != total {
+		return Distribution{}, fmt.Errorf("fetched %d validators but the stake hub has %d", len(votingPowers), total)
+	}
+
+	active, err := bscMiningValidators(stakeHub, evmContract{rpcURL: rpcURL, block: block, address: bscValidatorSet})
+	if err != nil {
+		return Distribution{}, err
+	}
 
-		// increment counters
-		pageOffset += pageLimit
+	stakes := make(map[string]*big.Int)
+	for _, v := range votingPowers {
+		stakes[v.Address] = v.VotingPower
 	}
 
-	// NOTE: NO need to calculate total voting power as the API response already
-	// sends us proportional shares of each validator
+	var activeVotingPowers []Validator
+	for _, operator := range active {
+		stake, ok := stakes[operator]
+		if !ok {
+			return Distribution{}, fmt.Errorf("mining validator %s is not registered in the stake hub", operator)
+		}
+		activeVotingPowers = append(activeVotingPowers, Validator{Address: operator, VotingPower: stake})
+	}
 
-	// now we're ready to calculate the nakomoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficient(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for BNB Smart Chain is", nakamotoCoefficient)
+	log.Printf("Fetched %d mining BSC validators out of %d candidates at block %s", len(activeVotingPowers), len(votingPowers), block)
 
-	return nakamotoCoefficient, nil
+	return Distribution{Validators: activeVotingPowers, Candidates: votingPowers}, nil
 }
 
-func weiToEther(wei *big.Int) int64 {
-	f := new(big.Float)
-	f.SetPrec(236) //  IEEE 754 octuple-precision binary floating-point format: binary256
-	f.SetMode(big.ToNearestEven)
-	fWei := new(big.Float)
-	fWei.SetPrec(236) //  IEEE 754 octuple-precision binary floating-point format: binary256
-	fWei.SetMode(big.ToNearestEven)
-	result, _ := f.Quo(fWei.SetInt(wei), big.NewFloat(1e18)).Int64()
-	return result
+// bscMiningValidators returns the operator addresses of the validators producing blocks in the current epoch.
+func bscMiningValidators(stakeHub, validatorSet evmContract) ([]string, error) {
+	res, err := validatorSet.call("getMiningValidators()")
+	if err != nil {
+		return nil, err
+	}
+
+	consensusAddrs, err := abiAddressArrayAt(res, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mining validators: %w", err)
+	}
+	if len(consensusAddrs) == 0 {
+		return nil, fmt.Errorf("no mining validators")
+	}
+
+	operators := make([]string, 0, len(consensusAddrs))
+	for _, consensusAddr := range consensusAddrs {
+		arg, err := abiAddressArg(consensusAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := stakeHub.call("consensusToOperator(address)", arg)
+		if err != nil {
+			return nil, err
+		}
+
+		operator, err := abiAddressAt(res, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode operator of %s: %w", consensusAddr, err)
+		}
+		operators = append(operators, operator)
+	}
+
+	return operators, nil
 }
@@ -2,13 +2,9 @@ package chains
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"math/big"
 	"net/http"
-	"sort"
-
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
 )
 
 type CardanoResponse struct {
@@ -18,20 +14,20 @@ type CardanoResponse struct {
 	Stake float64 `json:"stake"`
 }
 
-func Cardano() (int, error) {
+func Cardano() (Distribution, error) {
 	url := "https://www.balanceanalytics.io/api/mavdata.json"
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		log.Println("Error creating request:", err)
-		return 0, err
+		return Distribution{}, err
 	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Println("Error making request:", err)
-		return 0, err
+		return Distribution{}, err
 	}
 	defer resp.Body.Close()
 
@@ -41,29 +37,17 @@ func Cardano() (int, error) {
 	err = json.NewDecoder(resp.Body).Decode(&responseData)
 	if err != nil {
 		log.Println("Error decoding JSON:", err)
-		return 0, err
+		return Distribution{}, err
 	}
 
-	var votingPowers []big.Int
+	var votingPowers []Validator
 	for _, data := range responseData.ApiData {
-		stakeInt := big.NewInt(int64(data.Stake))
-		votingPowers = append(votingPowers, *stakeInt)
+		votingPowers = append(votingPowers, Validator{
+			Address:     data.Label,
+			VotingPower: big.NewInt(int64(data.Stake)),
+		})
 	}
 
-	// need to sort the powers in descending order since they are in random order
-	sort.Slice(votingPowers, func(i, j int) bool {
-		return votingPowers[i].Cmp(&votingPowers[j]) > 0
-	})
-
-	// Calculate total voting power
-	totalVotingPower := utils.CalculateTotalVotingPowerBigNums(votingPowers)
-
-	// Calculate Nakamoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums51(totalVotingPower, votingPowers)
-
-	fmt.Println("The total voting power for Cardano is: ", totalVotingPower)
-	fmt.Println("The Nakamoto coefficient for Cardano is: ", nakamotoCoefficient)
-
-	// Return Nakamoto coefficient
-	return nakamotoCoefficient, nil
+	// Cardano requires a majority of the stake to be controlled.
+	return Distribution{Validators: votingPowers, ThresholdPercent: 50}, nil
 }
@@ -3,20 +3,19 @@ package chains
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"time"
 )
 
-const nakamotoThreshold = 33
-
 type celestiaResp struct {
+	OperatorAddress    string  `json:"operatorAddress"`
 	Jailed             bool    `json:"jailed"`
 	VotingPowerPercent float64 `json:"votingPowerPercent"`
 }
 
-func Celestia() (int, error) {
+func Celestia() (Distribution, error) {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
 
@@ -24,39 +23,34 @@ func Celestia() (int, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	resp, err := new(http.Client).Do(req)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	var response []celestiaResp
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
-	var (
-		cumulativePower     float64
-		nakamotoCoefficient int
-	)
+	// The explorer only reports voting power percentages, which are scaled to integers.
+	var votingPowers []Validator
 	for _, resp := range response {
-		cumulativePower += resp.VotingPowerPercent
-		nakamotoCoefficient += 1
-		if cumulativePower > nakamotoThreshold {
-			break
-		}
+		votingPowers = append(votingPowers, Validator{
+			Address:     resp.OperatorAddress,
+			VotingPower: big.NewInt(int64(resp.VotingPowerPercent * 1e6)),
+		})
 	}
 
-	fmt.Printf("The Nakamoto coefficient for %s is %d\n", "celestia", nakamotoCoefficient)
-
-	return nakamotoCoefficient, nil
+	return Distribution{Validators: votingPowers}, nil
 }
@@ -9,6 +9,29 @@ import (
 type Chain struct {
 	PrevNCVal int
 	CurrNCVal int
+	// EntityNCVal is the coefficient after merging validators run by the same entity.
+	// It is zero when no entity mapping is known for the chain.
+	EntityNCVal int
+	// ControllerNCVal is the coefficient after attributing delegated stake to the liquid staking protocols
+	// and stake pools controlling it. It is zero when no controllers are known for the chain.
+	ControllerNCVal int
+	// CandidateNCVal is the coefficient of the full candidate set, for chains that elect an active set
+	// out of it. It is zero for other chains.
+	CandidateNCVal int
+	// NextEpochNCVal is the coefficient forecast for the next epoch from the validator set the chain published
+	// for it. It is zero for chains that do not publish the next set.
+	NextEpochNCVal int
+	// Metrics are the coefficients of the alternative weightings and infrastructure groupings of the chain, by name.
+	Metrics map[string]int
+	// CaptureProbabilities are, for k = 1, 2, ..., the probability that the k largest validators capture the
+	// committee of a round, for chains sampling committees by stake. It is empty for other chains.
+	CaptureProbabilities []float64
+	// EntityGroups are the validators the chain's fetcher grouped into entities, for review.
+	EntityGroups []EntityGroup
+	Sensitivity  Sensitivity
+	// Distribution holds the parts of the distribution the coefficients were calculated from that the API
+	// still serves: the validators, their total and threshold, the groupings and the warnings.
+	Distribution Distribution
 }
 
 // Token represents the name of token for a blockchain.
@@ -31,6 +54,7 @@ const (
 	BNB   Token = "BNB"
 	DOT   Token = "DOT"
 	EGLD  Token = "EGLD"
+	ETH   Token = "ETH"
 	GRT   Token = "GRT"
 	HBAR  Token = "HBAR"
 	JUNO  Token = "JUNO"
@@ -73,6 +97,8 @@ func (t Token) ChainName() string {
 		return "Polkadot"
 	case EGLD:
 		return "MultiversX"
+	case ETH:
+		return "Ethereum"
 	case GRT:
 		return "Graph Protocol"
 	case HBAR:
@@ -108,11 +134,18 @@ func (t Token) ChainName() string {
 	case XNO:
 		return "Nano"
 	default:
+		if chain, ok := lookupRegistryChain(t); ok {
+			return chain.PrettyName
+		}
+		if network, ok := lookupSubstrateChain(t); ok {
+			return substrateChainName(network)
+		}
+
 		return "Unknown"
 	}
 }
 
-var Tokens = []Token{ADA, ALGO, APT, ATOM, AVAIL, AVAX, BLD, BNB, DOT, EGLD, GRT, HBAR, JUNO, MATIC, MINA, NAM, NEAR, OSMO, PLS, REGEN, RUNE, SEI, SOL, STARS, SUI, TIA, XNO}
+var Tokens = []Token{ADA, ALGO, APT, ATOM, AVAIL, AVAX, BLD, BNB, DOT, EGLD, ETH, GRT, HBAR, JUNO, MATIC, MINA, NAM, NEAR, OSMO, PLS, REGEN, RUNE, SEI, SOL, STARS, SUI, TIA, XNO}
 
 // NewState returns a new fresh state.
 func NewState() ChainState {
@@ -121,104 +154,284 @@ func NewState() ChainState {
 	return RefreshChainState(state)
 }
 
+// RefreshChainState returns the current state of the supported chains and of the chains enabled
+// from the chain-registry or through Subscan.
 func RefreshChainState(prevState ChainState) ChainState {
+	tokens := append(append(Tokens, RegistryTokens()...), SubstrateTokens()...)
+
 	newState := make(ChainState)
-	for _, token := range Tokens {
-		currVal, err := newValues(token)
+	for _, token := range tokens {
+		chain, err := newValues(token)
 		if err != nil {
 			log.Println("Failed to update chain info:", token, err)
 			continue
 		}
 
-		newState[token] = Chain{
-			PrevNCVal: prevState[token].CurrNCVal,
-			CurrNCVal: currVal,
-		}
+		chain.PrevNCVal = prevState[token].CurrNCVal
+		newState[token] = chain
 	}
 
 	return newState
 }
 
-func newValues(token Token) (int, error) {
+// newValues returns the current coefficients of the given chain.
+func newValues(token Token) (Chain, error) {
+	dist, err := fetchDistribution(token)
+	if err != nil {
+		log.Printf("Error in chain %s: %v", token.ChainName(), err)
+		return Chain{}, err
+	}
+	if t, ok := thresholds[token]; ok {
+		if t.percent != 0 {
+			dist.ThresholdPercent = t.percent
+		}
+		dist.ThresholdInclusive = t.inclusive
+	}
+
+	metricName, entityHeadline := entityHeadlines[token]
+	if entityHeadline {
+		mapping, err := entityMapping(token, dist)
+		if err != nil {
+			log.Printf("Error in chain %s: %v", token.ChainName(), err)
+			return Chain{}, err
+		}
+
+		validators := dist
+		dist = dist.GroupByEntity(mapping)
+		dist.Alternatives = map[string]Distribution{metricName: {Validators: validators.Validators}}
+		for name, alt := range validators.Alternatives {
+			dist.Alternatives[name] = alt
+		}
+	}
+
+	currVal, sensitivity, err := dist.Calculate()
+	if err != nil {
+		log.Printf("Error in chain %s: %v", token.ChainName(), err)
+		return Chain{}, err
+	}
+
+	log.Printf("Successfully calculated Nakamoto coefficient for %s: %d", token.ChainName(), currVal)
+
+	// A missing or broken entity mapping should not hide the validator-level coefficient.
+	entityVal := currVal
+	if !entityHeadline {
+		entityVal, err = entityValue(token, dist)
+		if err != nil {
+			log.Printf("Failed to calculate entity-level Nakamoto coefficient for %s: %v", token.ChainName(), err)
+		}
+	}
+
+	var controllerVal int
+	if len(dist.Delegations) > 0 {
+		controllerVal, _, err = dist.GroupByController().Calculate()
+		if err != nil {
+			log.Printf("Failed to calculate controller-level Nakamoto coefficient for %s: %v", token.ChainName(), err)
+		}
+	}
+
+	var candidateVal int
+	if len(dist.Candidates) > 0 {
+		candidateVal, _, err = Distribution{Validators: dist.Candidates, ThresholdPercent: dist.ThresholdPercent, ThresholdInclusive: dist.ThresholdInclusive}.Calculate()
+		if err != nil {
+			log.Printf("Failed to calculate candidate set Nakamoto coefficient for %s: %v", token.ChainName(), err)
+		}
+	}
+
+	var nextEpochVal int
+	if len(dist.NextEpoch) > 0 {
+		nextEpochVal, _, err = Distribution{Validators: dist.NextEpoch, ThresholdPercent: dist.ThresholdPercent, ThresholdInclusive: dist.ThresholdInclusive}.Calculate()
+		if err != nil {
+			log.Printf("Failed to calculate next epoch Nakamoto coefficient for %s: %v", token.ChainName(), err)
+		}
+	}
+
+	// Infrastructure groupings are kept with the fetcher's own, so collusion groups can select by them.
+	groupings := make(map[string]EntityMapping)
+	for name, mapping := range infrastructureGroupings(dist.NetworkAddresses) {
+		groupings[name] = mapping
+	}
+	for name, mapping := range dist.Groupings {
+		groupings[name] = mapping
+	}
+	if len(groupings) > 0 {
+		dist.Groupings = groupings
+	}
+
+	alternatives := make(map[string]Distribution)
+	for name, alt := range dist.Alternatives {
+		alt.ThresholdPercent, alt.ThresholdInclusive = dist.ThresholdPercent, dist.ThresholdInclusive
+		alternatives[name] = alt
+	}
+	for name, mapping := range dist.Groupings {
+		alternatives[name] = dist.GroupByEntity(mapping)
+	}
+
+	var metrics map[string]int
+	for name, alt := range alternatives {
+		val, _, err := alt.Calculate()
+		if err != nil {
+			log.Printf("Failed to calculate %s Nakamoto coefficient for %s: %v", name, token.ChainName(), err)
+			continue
+		}
+
+		if metrics == nil {
+			metrics = make(map[string]int)
+		}
+		metrics[name] = val
+	}
+
+	var captureProbabilities []float64
+	if dist.Committee != nil {
+		captureProbabilities, err = dist.CaptureProbabilities(*dist.Committee)
+		if err != nil {
+			log.Printf("Failed to calculate committee capture probabilities for %s: %v", token.ChainName(), err)
+		}
+	}
+
+	return Chain{
+		CurrNCVal:            currVal,
+		EntityNCVal:          entityVal,
+		ControllerNCVal:      controllerVal,
+		CandidateNCVal:       candidateVal,
+		NextEpochNCVal:       nextEpochVal,
+		Metrics:              metrics,
+		CaptureProbabilities: captureProbabilities,
+		EntityGroups:         dist.EntityGroups,
+		Sensitivity:          sensitivity,
+		Distribution:         servedDistribution(dist),
+	}, nil
+}
+
+// servedDistribution returns the parts of a distribution needed to answer collusion requests and to name and
+// warn about validators, so the candidates, delegations, alternatives and addresses of a chain are not kept
+// between refreshes.
+func servedDistribution(dist Distribution) Distribution {
+	return Distribution{
+		Validators:         dist.Validators,
+		TotalVotingPower:   dist.TotalVotingPower,
+		ThresholdPercent:   dist.ThresholdPercent,
+		ThresholdInclusive: dist.ThresholdInclusive,
+		Groupings:          dist.Groupings,
+		Warnings:           dist.Warnings,
+	}
+}
+
+// threshold overrides how a chain's coefficient compares a coalition with its threshold.
+type threshold struct {
+	// percent replaces the fetcher's threshold percent unless zero.
+	percent   float64
+	inclusive bool
+}
+
+// thresholds keep the comparison each chain used before the sensitivity analysis, whatever source its
+// validators are fetched from, so their values stay comparable with the ones published before. These chains
+// count a coalition holding exactly the threshold, and Avail and Namada use a third of the stake.
+var thresholds = map[Token]threshold{
+	ALGO:  {inclusive: true},
+	AVAIL: {percent: 100.0 / 3, inclusive: true},
+	AVAX:  {inclusive: true},
+	BNB:   {inclusive: true},
+	DOT:   {inclusive: true},
+	EGLD:  {inclusive: true},
+	MATIC: {inclusive: true},
+	MINA:  {inclusive: true},
+	NAM:   {percent: 100.0 / 3, inclusive: true},
+	PLS:   {inclusive: true},
+	XNO:   {inclusive: true},
+}
+
+// fetchDistribution fetches the current voting power distribution of the given chain.
+func fetchDistribution(token Token) (Distribution, error) {
 	var (
-		currVal int
-		err     error
+		dist Distribution
+		err  error
 	)
 
 	log.Printf("Calculating Nakamoto coefficient for %s", token.ChainName())
 
+	if rpcURL := cometBFTRPCURL(token); rpcURL != "" {
+		return FetchCometBFTValidators(rpcURL, 0)
+	}
+	if rpcURL := substrateRPCURL(token); rpcURL != "" {
+		return FetchSubstrateExposures(rpcURL, -1)
+	}
+
 	switch token {
 	case ADA:
-		currVal, err = Cardano()
+		dist, err = Cardano()
 	case ALGO:
-		currVal, err = Algorand()
+		dist, err = Algorand()
 	case APT:
-		currVal, err = Aptos()
+		dist, err = Aptos()
 	case ATOM:
-		currVal, err = Cosmos()
+		dist, err = Cosmos()
 	case AVAIL:
-		currVal, err = Avail()
+		dist, err = Avail()
 	case AVAX:
-		currVal, err = Avalanche()
+		dist, err = Avalanche()
 	case BLD:
-		currVal, err = Agoric()
+		dist, err = Agoric()
 	case BNB:
-		currVal, err = BSC()
+		dist, err = BSC()
 	case DOT:
-		currVal, err = Polkadot()
+		dist, err = Polkadot()
 	case EGLD:
-		currVal, err = MultiversX()
+		dist, err = MultiversX()
+	case ETH:
+		dist, err = Ethereum()
 	case GRT:
-		currVal, err = Graph()
+		dist, err = Graph()
 	case HBAR:
-		currVal, err = Hedera()
+		dist, err = Hedera()
 	case JUNO:
-		currVal, err = Juno()
+		dist, err = Juno()
 	case MATIC:
-		currVal, err = Polygon()
+		dist, err = Polygon()
 	case MINA:
-		currVal, err = Mina()
+		dist, err = Mina()
 	case NAM:
-		currVal, err = Namada()
+		dist, err = Namada()
 	case NEAR:
-		currVal, err = Near()
+		dist, err = Near()
 	case OSMO:
-		currVal, err = Osmosis()
+		dist, err = Osmosis()
 	case PLS:
-		currVal, err = Pulsechain()
+		dist, err = Pulsechain()
 	case REGEN:
-		currVal, err = Regen()
+		dist, err = Regen()
 	case RUNE:
-		currVal, err = Thorchain()
+		dist, err = Thorchain()
 	case SEI:
 		log.Println("Attempting to calculate Sei Nakamoto coefficient...")
-		currVal, err = Sei()
+		dist, err = Sei()
 		if err != nil {
 			log.Printf("Error calculating Sei Nakamoto coefficient: %v", err)
 		}
 	case SOL:
-		currVal, err = Solana()
+		dist, err = Solana()
 	case STARS:
 		log.Println("Attempting to calculate Stargaze Nakamoto coefficient...")
-		currVal, err = Stargaze()
+		dist, err = Stargaze()
 		if err != nil {
 			log.Printf("Error calculating Stargaze Nakamoto coefficient: %v", err)
 		}
 	case SUI:
-		currVal, err = Sui()
+		dist, err = Sui()
 	case TIA:
-		currVal, err = Celestia()
+		dist, err = Celestia()
 	case XNO:
-		currVal, err = Nano()
+		dist, err = Nano()
 	default:
-		return 0, fmt.Errorf("chain not found: %s", token)
-	}
+		if network, ok := lookupSubstrateChain(token); ok {
+			return FetchSubscanValidators(token, subscanAPIURL(network))
+		}
 
-	if err != nil {
-		log.Printf("Error in chain %s: %v", token.ChainName(), err)
-	} else {
-		log.Printf("Successfully calculated Nakamoto coefficient for %s: %d", token.ChainName(), currVal)
+		chain, ok := lookupRegistryChain(token)
+		if !ok {
+			return Distribution{}, fmt.Errorf("chain not found: %s", token)
+		}
+		dist, err = fetchRegistryChain(token, chain)
 	}
 
-	return currVal, err
+	return dist, err
 }
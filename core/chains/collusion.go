@@ -0,0 +1,86 @@
+package chains
+
+import (
+	"fmt"
+)
+
+// CollusionGroup is a set of validators assumed to act as one, for example all validators
+// in the same jurisdiction in a regulatory capture scenario. The validators are listed, selected
+// by the value of one of the chain's groupings, such as "country" and "US", or both.
+type CollusionGroup struct {
+	Name       string   `json:"name"`
+	Validators []string `json:"validators"`
+	Grouping   string   `json:"grouping,omitempty"`
+	Value      string   `json:"value,omitempty"`
+}
+
+// CollusionResult is the Nakamoto coefficient of a chain recomputed for a set of collusion groups.
+type CollusionResult struct {
+	CurrNCVal      int
+	CollusionNCVal int
+	// Matched is the number of validators of each group found in the chain's distribution.
+	Matched map[string]int
+}
+
+// Collude recomputes the Nakamoto coefficient of the chain's last fetched distribution
+// assuming the validators of each group collude. A validator listed in several groups
+// belongs to the last one. Group names must be unique.
+func (c Chain) Collude(groups []CollusionGroup) (CollusionResult, error) {
+	if len(c.Distribution.Validators) == 0 {
+		return CollusionResult{}, fmt.Errorf("no distribution available")
+	}
+
+	known := make(map[string]bool)
+	for _, v := range c.Distribution.Validators {
+		known[v.Address] = true
+	}
+
+	var (
+		mapping = make(EntityMapping)
+		matched = make(map[string]int)
+	)
+	for _, group := range groups {
+		if group.Name == "" {
+			return CollusionResult{}, fmt.Errorf("collusion group without a name")
+		}
+		if _, ok := matched[group.Name]; ok {
+			return CollusionResult{}, fmt.Errorf("duplicate collusion group %s", group.Name)
+		}
+		matched[group.Name] = 0
+
+		addrs := append([]string(nil), group.Validators...)
+		if group.Grouping != "" {
+			grouping, ok := c.Distribution.Groupings[group.Grouping]
+			if !ok {
+				return CollusionResult{}, fmt.Errorf("grouping %q of collusion group %s is not known for this chain", group.Grouping, group.Name)
+			}
+			for addr, value := range grouping {
+				if value == group.Value {
+					addrs = append(addrs, addr)
+				}
+			}
+		}
+
+		for _, addr := range addrs {
+			mapping[addr] = group.Name
+		}
+	}
+
+	// Count the validators each group ends up with, after later groups took over some of them.
+	for addr, name := range mapping {
+		if known[addr] {
+			matched[name]++
+		}
+	}
+
+	val, _, err := c.Distribution.GroupByEntity(mapping).Calculate()
+	if err != nil {
+		return CollusionResult{}, err
+	}
+
+	return CollusionResult{
+		CurrNCVal:      c.CurrNCVal,
+		CollusionNCVal: val,
+		Matched:        matched,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package chains
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestCollude(t *testing.T) {
+	chain := Chain{
+		CurrNCVal: 4,
+		Distribution: Distribution{
+			Validators: []Validator{
+				{Address: "a", VotingPower: big.NewInt(10)},
+				{Address: "b", VotingPower: big.NewInt(10)},
+				{Address: "c", VotingPower: big.NewInt(10)},
+				{Address: "d", VotingPower: big.NewInt(10)},
+				{Address: "e", VotingPower: big.NewInt(10)},
+				{Address: "f", VotingPower: big.NewInt(10)},
+				{Address: "g", VotingPower: big.NewInt(10)},
+				{Address: "h", VotingPower: big.NewInt(10)},
+				{Address: "i", VotingPower: big.NewInt(10)},
+				{Address: "j", VotingPower: big.NewInt(10)},
+			},
+			Groupings: map[string]EntityMapping{"country": {"c": "US", "d": "US", "e": "DE"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		groups      []CollusionGroup
+		wantVal     int
+		wantMatched map[string]int
+		wantErr     bool
+	}{
+		{
+			name:        "listed",
+			groups:      []CollusionGroup{{Name: "x", Validators: []string{"a", "b", "c", "unknown"}}},
+			wantVal:     2,
+			wantMatched: map[string]int{"x": 3},
+		},
+		{
+			name: "taken over",
+			groups: []CollusionGroup{
+				{Name: "x", Validators: []string{"a", "b"}},
+				{Name: "y", Validators: []string{"b", "c"}},
+			},
+			wantVal:     3,
+			wantMatched: map[string]int{"x": 1, "y": 2},
+		},
+		{
+			name:        "by grouping",
+			groups:      []CollusionGroup{{Name: "us", Validators: []string{"a"}, Grouping: "country", Value: "US"}},
+			wantVal:     2,
+			wantMatched: map[string]int{"us": 3},
+		},
+		{
+			name:    "duplicate name",
+			groups:  []CollusionGroup{{Name: "x", Validators: []string{"a"}}, {Name: "x", Validators: []string{"b"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown grouping",
+			groups:  []CollusionGroup{{Name: "x", Grouping: "asn", Value: "1"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := chain.Collude(tt.groups)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.CurrNCVal != chain.CurrNCVal {
+				t.Errorf("current value = %d, want %d", res.CurrNCVal, chain.CurrNCVal)
+			}
+			if res.CollusionNCVal != tt.wantVal {
+				t.Errorf("collusion value = %d, want %d", res.CollusionNCVal, tt.wantVal)
+			}
+			if fmt.Sprint(res.Matched) != fmt.Sprint(tt.wantMatched) {
+				t.Errorf("matched = %v, want %v", res.Matched, tt.wantMatched)
+			}
+		})
+	}
+
+	t.Run("groups unchanged", func(t *testing.T) {
+		validators := make([]string, 1, 2)
+		validators[0] = "a"
+		if _, err := chain.Collude([]CollusionGroup{{Name: "us", Validators: validators, Grouping: "country", Value: "US"}}); err != nil {
+			t.Fatal(err)
+		}
+		if extra := validators[:2][1]; extra != "" {
+			t.Errorf("collusion group validators were appended to: %q", extra)
+		}
+	})
+}
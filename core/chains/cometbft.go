@@ -0,0 +1,203 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cometBFTPerPage is the number of validators requested per page, the maximum allowed by CometBFT.
+const cometBFTPerPage = 100
+
+// cometBFTChains are the chains whose data can be read from a CometBFT RPC endpoint instead of their default source.
+var cometBFTChains = map[Token]bool{
+	ATOM:  true,
+	BLD:   true,
+	JUNO:  true,
+	NAM:   true,
+	OSMO:  true,
+	REGEN: true,
+	RUNE:  true,
+	SEI:   true,
+	STARS: true,
+	TIA:   true,
+}
+
+type cometBFTValidatorsResponse struct {
+	Result struct {
+		BlockHeight string `json:"block_height"`
+		Validators  []struct {
+			Address     string `json:"address"`
+			VotingPower string `json:"voting_power"`
+		} `json:"validators"`
+		Count string `json:"count"`
+		Total string `json:"total"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+type cometBFTNetInfoResponse struct {
+	Result struct {
+		Peers []cometBFTPeer `json:"peers"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+type cometBFTPeer struct {
+	NodeInfo struct {
+		ID      string `json:"id"`
+		Moniker string `json:"moniker"`
+	} `json:"node_info"`
+	RemoteIP string `json:"remote_ip"`
+}
+
+// cometBFTRPCURL returns the CometBFT RPC endpoint configured for the chain through COMETBFT_RPC_<TOKEN>, if any.
+func cometBFTRPCURL(token Token) string {
+	if _, ok := lookupRegistryChain(token); !ok && !cometBFTChains[token] {
+		return ""
+	}
+
+	return os.Getenv("COMETBFT_RPC_" + string(token))
+}
+
+// FetchCometBFTValidators returns the consensus voting power distribution from the /validators endpoint
+// of a CometBFT RPC node at the given height, or at the latest height if zero. Validators are identified
+// by their consensus address.
+func FetchCometBFTValidators(rpcURL string, height int64) (Distribution, error) {
+	var (
+		votingPowers []Validator
+		total        int
+	)
+
+	rpcURL = strings.TrimSuffix(rpcURL, "/")
+	for page := 1; ; page++ {
+		pageURL := fmt.Sprintf("%s/validators?page=%d&per_page=%d", rpcURL, page, cometBFTPerPage)
+		if height > 0 {
+			pageURL += fmt.Sprintf("&height=%d", height)
+		}
+
+		response, err := fetchCometBFTValidatorsPage(pageURL)
+		if err != nil {
+			return Distribution{}, err
+		}
+
+		// Pin the remaining pages to the height of the first one, so they describe the same validator set.
+		if height == 0 {
+			height, err = strconv.ParseInt(response.Result.BlockHeight, 10, 64)
+			if err != nil {
+				return Distribution{}, fmt.Errorf("failed to parse block height %q", response.Result.BlockHeight)
+			}
+		}
+
+		total, err = strconv.Atoi(response.Result.Total)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to parse validators total %q", response.Result.Total)
+		}
+
+		for _, v := range response.Result.Validators {
+			vp, ok := new(big.Int).SetString(v.VotingPower, 10)
+			if !ok {
+				return Distribution{}, fmt.Errorf("failed to parse voting power %q of %s", v.VotingPower, v.Address)
+			}
+			votingPowers = append(votingPowers, Validator{Address: v.Address, VotingPower: vp})
+		}
+
+		if len(response.Result.Validators) == 0 || len(votingPowers) >= total {
+			break
+		}
+	}
+
+	if len(votingPowers) != total {
+		return Distribution{}, fmt.Errorf("fetched %d validators but the validator set has %d", len(votingPowers), total)
+	}
+
+	log.Printf("Fetched %d validators at height %d from %s", len(votingPowers), height, rpcURL)
+
+	return Distribution{Validators: votingPowers}, nil
+}
+
+func fetchCometBFTValidatorsPage(url string) (cometBFTValidatorsResponse, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Println(err)
+		return cometBFTValidatorsResponse{}, errors.New("create get request for cometbft validators")
+	}
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return cometBFTValidatorsResponse{}, errors.New("get request unsuccessful for cometbft validators")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cometBFTValidatorsResponse{}, err
+	}
+
+	var response cometBFTValidatorsResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return cometBFTValidatorsResponse{}, err
+	}
+	if response.Error != nil {
+		return cometBFTValidatorsResponse{}, fmt.Errorf("rpc error %d: %s %s", response.Error.Code, response.Error.Message, response.Error.Data)
+	}
+
+	return response, nil
+}
+
+// fetchCometBFTPeers returns the peers the CometBFT node at rpcURL is connected to, from its /net_info endpoint.
+func fetchCometBFTPeers(rpcURL string) ([]cometBFTPeer, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(rpcURL, "/")+"/net_info", nil)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("create get request for cometbft net info")
+	}
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("get request unsuccessful for cometbft net info")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var response cometBFTNetInfoResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("rpc error %d: %s %s", response.Error.Code, response.Error.Message, response.Error.Data)
+	}
+
+	return response.Result.Peers, nil
+}
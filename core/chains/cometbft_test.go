@@ -0,0 +1,105 @@
+package chains
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// cometBFTStandIn serves the /validators endpoint of a CometBFT node over a validator set of the given size,
+// reporting latestHeight when no height is requested and recording the height of every request.
+type cometBFTStandIn struct {
+	validators   int
+	total        int
+	latestHeight string
+
+	mu      sync.Mutex
+	heights []string
+}
+
+func (s *cometBFTStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/validators" {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	height := query.Get("height")
+
+	s.mu.Lock()
+	s.heights = append(s.heights, height)
+	s.mu.Unlock()
+
+	if height == "" {
+		height = s.latestHeight
+	}
+
+	var response cometBFTValidatorsResponse
+	response.Result.BlockHeight = height
+	response.Result.Total = strconv.Itoa(s.total)
+	for i := (page - 1) * perPage; i < page*perPage && i < s.validators; i++ {
+		response.Result.Validators = append(response.Result.Validators, struct {
+			Address     string `json:"address"`
+			VotingPower string `json:"voting_power"`
+		}{Address: fmt.Sprintf("VAL%03d", i), VotingPower: strconv.Itoa(1000 - i)})
+	}
+	response.Result.Count = strconv.Itoa(len(response.Result.Validators))
+
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestFetchCometBFTValidators(t *testing.T) {
+	tests := []struct {
+		name        string
+		validators  int
+		total       int
+		height      int64
+		wantHeights []string
+		wantErr     bool
+	}{
+		{name: "single page", validators: 40, total: 40, wantHeights: []string{""}},
+		{name: "latest height pinned", validators: 250, total: 250, wantHeights: []string{"", "1234", "1234"}},
+		{name: "requested height", validators: 150, total: 150, height: 99, wantHeights: []string{"99", "99"}},
+		{name: "truncated set", validators: 150, total: 160, wantHeights: []string{"", "1234", "1234"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standIn := &cometBFTStandIn{validators: tt.validators, total: tt.total, latestHeight: "1234"}
+			server := httptest.NewServer(standIn)
+			defer server.Close()
+
+			dist, err := FetchCometBFTValidators(server.URL+"/", tt.height)
+			if fmt.Sprint(standIn.heights) != fmt.Sprint(tt.wantHeights) {
+				t.Errorf("requested heights = %q, want %q", standIn.heights, tt.wantHeights)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(dist.Validators) != tt.validators {
+				t.Fatalf("got %d validators, want %d", len(dist.Validators), tt.validators)
+			}
+			last := dist.Validators[len(dist.Validators)-1]
+			if want := fmt.Sprintf("VAL%03d", tt.validators-1); last.Address != want {
+				t.Errorf("last validator = %s, want %s", last.Address, want)
+			}
+			if want := big.NewInt(int64(1000 - tt.validators + 1)); last.VotingPower.Cmp(want) != 0 {
+				t.Errorf("last voting power = %s, want %s", last.VotingPower, want)
+			}
+		})
+	}
+}
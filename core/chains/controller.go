@@ -0,0 +1,100 @@
+package chains
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Controller is a liquid staking protocol or stake pool that decides where the stake of its users is delegated.
+type Controller struct {
+	Name string `json:"name"`
+	// Delegators are the accounts holding the controller's stake: the delegator addresses of a Cosmos SDK
+	// liquid staking module or the stake authorities of a Solana stake pool.
+	Delegators []string `json:"delegators"`
+}
+
+// Delegation is stake that a controller delegated to a validator.
+type Delegation struct {
+	Controller string
+	Validator  string
+	Amount     *big.Int
+}
+
+// controllerFile is the on-disk format of the controllers of a chain:
+//
+//	{"controllers": [{"name": "Stride", "delegators": ["cosmos1..."]}]}
+type controllerFile struct {
+	Controllers []Controller `json:"controllers"`
+}
+
+// loadControllers returns the known controllers of the given chain.
+// They are read from CONTROLLERS_<TOKEN>, then from <CONTROLLERS_DIR>/<token>.json.
+func loadControllers(token Token) ([]Controller, error) {
+	source := configSource(token, "CONTROLLERS_", "CONTROLLERS_DIR", "controllers")
+	if source == "" {
+		return nil, nil
+	}
+
+	body, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var file controllerFile
+	if err := json.Unmarshal(body, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse controllers %s: %w", source, err)
+	}
+
+	return file.Controllers, nil
+}
+
+// GroupByController returns a distribution where the stake controllers delegated to validators
+// is attributed to the controllers. Validators keep the rest of their stake.
+func (d Distribution) GroupByController() Distribution {
+	var (
+		grouped     = d
+		remaining   = make(map[string]*big.Int)
+		controlled  = make(map[string]*big.Int)
+		controllers []string
+	)
+
+	for _, v := range d.Validators {
+		if v.Address != "" && v.VotingPower != nil {
+			remaining[v.Address] = new(big.Int).Set(v.VotingPower)
+		}
+	}
+
+	for _, del := range d.Delegations {
+		stake, ok := remaining[del.Validator]
+		if !ok || del.Amount == nil {
+			// The validator is not part of the active set.
+			continue
+		}
+
+		amount := new(big.Int).Set(del.Amount)
+		if amount.Cmp(stake) > 0 {
+			amount.Set(stake)
+		}
+		stake.Sub(stake, amount)
+
+		if _, ok := controlled[del.Controller]; !ok {
+			controlled[del.Controller] = new(big.Int)
+			controllers = append(controllers, del.Controller)
+		}
+		controlled[del.Controller].Add(controlled[del.Controller], amount)
+	}
+
+	grouped.Validators = nil
+	for _, v := range d.Validators {
+		if stake, ok := remaining[v.Address]; ok {
+			v.VotingPower = stake
+		}
+		grouped.Validators = append(grouped.Validators, v)
+	}
+	for _, name := range controllers {
+		grouped.Validators = append(grouped.Validators, Validator{Address: name, VotingPower: controlled[name]})
+	}
+
+	return grouped
+}
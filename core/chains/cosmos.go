@@ -9,34 +9,56 @@ import (
 	"log"
 	"math/big"
 	"net/http"
-	"sort"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
-
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
 )
 
 const BONDED = "BOND_STATUS_BONDED"
 
-func Cosmos() (int, error) {
-	validatorDataURL := "https://proxy.atomscan.com/cosmoshub-lcd/cosmos/staking/v1beta1/validators?page.offset=1&pagination.limit=500&status=BOND_STATUS_BONDED"
+const (
+	// cosmosPageLimit is the number of validators requested per page.
+	cosmosPageLimit = 200
+	// cosmosPoolTolerance is the divergence, in parts per million, tolerated between the bonded pool and the
+	// summed validator tokens. Both are fetched in separate requests, so they may be a few blocks apart.
+	cosmosPoolTolerance = 1000
+	// cosmosHeightHeader selects the height at which an archive node answers a REST query.
+	cosmosHeightHeader = "x-cosmos-block-height"
+)
+
+func Cosmos() (Distribution, error) {
+	validatorDataURL := "https://proxy.atomscan.com/cosmoshub-lcd/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED"
 	stakingPoolURL := "https://proxy.atomscan.com/cosmoshub-lcd/cosmos/staking/v1beta1/pool"
 
-	return FetchCosmosSDKNakaCoeff("cosmos", validatorDataURL, stakingPoolURL)
+	return FetchCosmosSDKNakaCoeff(ATOM, validatorDataURL, stakingPoolURL)
 }
 
 type cosmosValidatorData struct {
-	Validators []struct {
-		OperatorAddress string `json:"operator_address"`
-		ConsensusPubkey struct {
-			Type string `json:"@type"`
-			Key  string `json:"key"`
-		} `json:"consensus_pubkey"`
-		Jailed          bool   `json:"jailed"`
-		Status          string `json:"status"`
-		Tokens          string `json:"tokens"`
-		DelegatorShares string `json:"delegator_shares"`
-	} `json:"validators"`
+	Validators []cosmosValidator `json:"validators"`
+	Pagination struct {
+		NextKey string `json:"next_key"`
+	} `json:"pagination"`
+}
+
+type cosmosValidator struct {
+	OperatorAddress string `json:"operator_address"`
+	ConsensusPubkey struct {
+		Type string `json:"@type"`
+		Key  string `json:"key"`
+	} `json:"consensus_pubkey"`
+	Jailed          bool   `json:"jailed"`
+	Status          string `json:"status"`
+	Tokens          string `json:"tokens"`
+	DelegatorShares string `json:"delegator_shares"`
+	Description     struct {
+		Moniker         string `json:"moniker"`
+		Identity        string `json:"identity"`
+		Website         string `json:"website"`
+		SecurityContact string `json:"security_contact"`
+		Details         string `json:"details"`
+	} `json:"description"`
 }
 
 type cosmosStakingPoolData struct {
@@ -46,10 +68,18 @@ type cosmosStakingPoolData struct {
 	} `json:"pool"`
 }
 
-// fetchCosmosSDKNakaCoeff returns the nakamoto coefficient for a given cosmos SDK-based chain through REST API.
-func FetchCosmosSDKNakaCoeff(chainName, validatorURL, poolURL string) (int, error) {
+// FetchCosmosSDKNakaCoeff returns the voting power distribution for a given cosmos SDK-based chain through REST API.
+// All pages of validatorURL are fetched, and the bonded tokens of the validators must match the staking pool.
+func FetchCosmosSDKNakaCoeff(token Token, validatorURL, poolURL string) (Distribution, error) {
+	return FetchCosmosSDKNakaCoeffAtHeight(token, validatorURL, poolURL, 0)
+}
+
+// FetchCosmosSDKNakaCoeffAtHeight is like FetchCosmosSDKNakaCoeff but queries the state at a past height,
+// which requires an archive node. The latest height is used if zero.
+func FetchCosmosSDKNakaCoeffAtHeight(token Token, validatorURL, poolURL string, height int64) (Distribution, error) {
 	var (
-		votingPowers []big.Int
+		chainName    = token.ChainName()
+		votingPowers []Validator
 		validators   cosmosValidatorData
 		pool         cosmosStakingPoolData
 		err          error
@@ -58,58 +88,167 @@ func FetchCosmosSDKNakaCoeff(chainName, validatorURL, poolURL string) (int, erro
 	log.Printf("Fetching data for %s", chainName)
 
 	// Fetch the validator data
-	validators, err = fetchValidatorData(validatorURL)
+	validators, err = fetchAllValidatorData(validatorURL, height)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch validator data for %s: %w", chainName, err)
+		return Distribution{}, fmt.Errorf("failed to fetch validator data for %s: %w", chainName, err)
 	}
 
 	// Fetch the staking pool data to get the total bonded tokens
-	pool, err = fetchStakingPoolData(poolURL)
+	pool, err = fetchStakingPoolData(poolURL, height)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch pool data for %s: %w", chainName, err)
+		return Distribution{}, fmt.Errorf("failed to fetch pool data for %s: %w", chainName, err)
 	}
 
 	// Convert the bonded tokens from the pool response
-	totalVotingPower, ok := new(big.Int).SetString(pool.Pool.BondedTokens, 10)
+	poolBonded, ok := new(big.Int).SetString(pool.Pool.BondedTokens, 10)
 	if !ok {
-		return 0, errors.New("failed to convert bonded tokens to big.Int")
+		return Distribution{}, errors.New("failed to convert bonded tokens to big.Int")
 	}
 
 	// Loop through the validators' voting powers
+	var (
+		bonded           []cosmosValidator
+		totalVotingPower = new(big.Int)
+	)
 	for _, ele := range validators.Validators {
-		if ele.Status != BONDED {
+		if ele.Status != BONDED || ele.Jailed {
 			continue
 		}
 
-		val, err := strconv.Atoi(ele.Tokens)
-		if err != nil {
-			log.Printf("Error parsing token value for %s: %s", chainName, ele.Tokens)
-			continue
+		val, ok := new(big.Int).SetString(ele.Tokens, 10)
+		if !ok {
+			return Distribution{}, fmt.Errorf("failed to parse tokens %q of %s for %s", ele.Tokens, ele.OperatorAddress, chainName)
 		}
-		votingPowers = append(votingPowers, *big.NewInt(int64(val)))
+		votingPowers = append(votingPowers, Validator{
+			Address:     ele.OperatorAddress,
+			VotingPower: val,
+		})
+		totalVotingPower.Add(totalVotingPower, val)
+		bonded = append(bonded, ele)
 	}
 
 	// Summarize voting powers for logging
 	log.Printf("Voting powers for %s: %d validators with a total voting power of %s", chainName, len(votingPowers), totalVotingPower.String())
 
 	if len(votingPowers) == 0 {
-		return 0, fmt.Errorf("no valid voting powers found for %s", chainName)
+		return Distribution{}, fmt.Errorf("no valid voting powers found for %s", chainName)
 	}
 
-	// Sort the powers in descending order since they may be in random order
-	sort.Slice(votingPowers, func(i, j int) bool {
-		return votingPowers[i].Cmp(&votingPowers[j]) > 0
-	})
+	// A mismatch means validators are missing, for example because the endpoint truncated the list.
+	if !withinTolerance(totalVotingPower, poolBonded, cosmosPoolTolerance) {
+		return Distribution{}, fmt.Errorf("bonded tokens of validators %s diverge from staking pool %s for %s",
+			totalVotingPower.String(), poolBonded.String(), chainName)
+	}
+
+	// Liquid staking protocols are optional, so failing to resolve their delegations is not fatal.
+	delegations, err := fetchCosmosControllerDelegations(token, validatorURL, height)
+	if err != nil {
+		log.Printf("Failed to fetch controller delegations for %s: %v", chainName, err)
+	}
 
-	// Calculate the Nakamoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums(totalVotingPower, votingPowers)
-	log.Printf("The Nakamoto coefficient for %s is %d", chainName, nakamotoCoefficient)
+	// Peer addresses describe the network now, so they are only matched to the latest state.
+	var networkAddresses map[string]string
+	if rpcURL := os.Getenv("NET_INFO_RPC_" + string(token)); rpcURL != "" && height == 0 {
+		networkAddresses, err = fetchCosmosNetworkAddresses(rpcURL, bonded)
+		if err != nil {
+			log.Printf("Failed to fetch peer addresses for %s: %v", chainName, err)
+		}
+	}
 
-	return nakamotoCoefficient, nil
+	return Distribution{
+		Validators:       votingPowers,
+		EntityGroups:     detectCosmosEntities(bonded),
+		Delegations:      delegations,
+		NetworkAddresses: networkAddresses,
+	}, nil
+}
+
+// fetchCosmosNetworkAddresses returns the IP address of the validators whose moniker is also the moniker of
+// exactly one peer of the CometBFT node at rpcURL. Only validators peering with the node, or exposing sentries
+// under the same moniker, are found.
+func fetchCosmosNetworkAddresses(rpcURL string, validators []cosmosValidator) (map[string]string, error) {
+	peers, err := fetchCometBFTPeers(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ips    = make(map[string]string)
+		counts = make(map[string]int)
+	)
+	for _, peer := range peers {
+		moniker := strings.TrimSpace(peer.NodeInfo.Moniker)
+		ips[moniker] = peer.RemoteIP
+		counts[moniker]++
+	}
+
+	networkAddresses := make(map[string]string)
+	for _, v := range validators {
+		moniker := strings.TrimSpace(v.Description.Moniker)
+		if ip, ok := ips[moniker]; ok && moniker != "" && counts[moniker] == 1 {
+			networkAddresses[v.OperatorAddress] = ip
+		}
+	}
+
+	log.Printf("Matched %d of %d validators to peers of %s", len(networkAddresses), len(validators), rpcURL)
+
+	return networkAddresses, nil
+}
+
+// fetchAllValidatorData fetches all pages of the validator set by following the pagination key.
+func fetchAllValidatorData(validatorURL string, height int64) (cosmosValidatorData, error) {
+	var (
+		res     cosmosValidatorData
+		nextKey string
+		seen    = make(map[string]bool)
+	)
+
+	sep := "?"
+	if strings.Contains(validatorURL, "?") {
+		sep = "&"
+	}
+
+	for {
+		pageURL := fmt.Sprintf("%s%spagination.limit=%d", validatorURL, sep, cosmosPageLimit)
+		if nextKey != "" {
+			pageURL += "&pagination.key=" + url.QueryEscape(nextKey)
+		}
+
+		page, err := fetchValidatorData(pageURL, height)
+		if err != nil {
+			return cosmosValidatorData{}, err
+		}
+
+		res.Validators = append(res.Validators, page.Validators...)
+
+		nextKey = page.Pagination.NextKey
+		if nextKey == "" {
+			return res, nil
+		}
+		if seen[nextKey] {
+			return cosmosValidatorData{}, fmt.Errorf("validator pagination repeats key %q", nextKey)
+		}
+		seen[nextKey] = true
+	}
+}
+
+// withinTolerance returns true if a and b differ by at most tolerance parts per million of b.
+func withinTolerance(a, b *big.Int, tolerance int64) bool {
+	diff := new(big.Int).Sub(a, b)
+	diff.Abs(diff).Mul(diff, big.NewInt(1_000_000))
+
+	return diff.Cmp(new(big.Int).Mul(b, big.NewInt(tolerance))) <= 0
+}
+
+// setCosmosHeight makes the request query the state at the given height, if not zero.
+func setCosmosHeight(req *http.Request, height int64) {
+	if height > 0 {
+		req.Header.Set(cosmosHeightHeader, strconv.FormatInt(height, 10))
+	}
 }
 
 // Fetches data on active validator set
-func fetchValidatorData(url string) (cosmosValidatorData, error) {
+func fetchValidatorData(url string, height int64) (cosmosValidatorData, error) {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
 
@@ -118,6 +257,7 @@ func fetchValidatorData(url string) (cosmosValidatorData, error) {
 		log.Println(err)
 		return cosmosValidatorData{}, errors.New("create get request for cosmos validators")
 	}
+	setCosmosHeight(req, height)
 
 	resp, err := new(http.Client).Do(req)
 	if err != nil {
@@ -130,6 +270,9 @@ func fetchValidatorData(url string) (cosmosValidatorData, error) {
 	if err != nil {
 		return cosmosValidatorData{}, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		return cosmosValidatorData{}, fmt.Errorf("cosmos validators request failed: %d", resp.StatusCode)
+	}
 
 	var response cosmosValidatorData
 	err = json.Unmarshal(body, &response)
@@ -141,7 +284,7 @@ func fetchValidatorData(url string) (cosmosValidatorData, error) {
 }
 
 // Fetches staking pool data incl bonded and not_bonded tokens
-func fetchStakingPoolData(url string) (cosmosStakingPoolData, error) {
+func fetchStakingPoolData(url string, height int64) (cosmosStakingPoolData, error) {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
 
@@ -150,6 +293,7 @@ func fetchStakingPoolData(url string) (cosmosStakingPoolData, error) {
 		log.Println(err)
 		return cosmosStakingPoolData{}, errors.New("create get request for cosmos pool")
 	}
+	setCosmosHeight(req, height)
 
 	resp, err := new(http.Client).Do(req)
 	if err != nil {
@@ -162,6 +306,9 @@ func fetchStakingPoolData(url string) (cosmosStakingPoolData, error) {
 	if err != nil {
 		return cosmosStakingPoolData{}, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		return cosmosStakingPoolData{}, fmt.Errorf("cosmos pool request failed: %d", resp.StatusCode)
+	}
 
 	var response cosmosStakingPoolData
 	err = json.Unmarshal(body, &response)
@@ -0,0 +1,130 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type cosmosDelegationsData struct {
+	DelegationResponses []struct {
+		Delegation struct {
+			DelegatorAddress string `json:"delegator_address"`
+			ValidatorAddress string `json:"validator_address"`
+		} `json:"delegation"`
+		Balance struct {
+			Denom  string `json:"denom"`
+			Amount string `json:"amount"`
+		} `json:"balance"`
+	} `json:"delegation_responses"`
+	Pagination struct {
+		NextKey string `json:"next_key"`
+	} `json:"pagination"`
+}
+
+// fetchCosmosControllerDelegations returns the delegations of the known controllers of a Cosmos SDK chain,
+// using the same REST API as the validators URL.
+func fetchCosmosControllerDelegations(token Token, validatorURL string, height int64) ([]Delegation, error) {
+	controllers, err := loadControllers(token)
+	if err != nil || len(controllers) == 0 {
+		return nil, err
+	}
+
+	i := strings.Index(validatorURL, "/cosmos/staking/")
+	if i < 0 {
+		return nil, fmt.Errorf("unexpected validators url %s", validatorURL)
+	}
+	baseURL := validatorURL[:i]
+
+	var delegations []Delegation
+	for _, controller := range controllers {
+		for _, delegator := range controller.Delegators {
+			dels, err := fetchCosmosDelegations(baseURL, delegator, height)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch delegations of %s: %w", controller.Name, err)
+			}
+
+			for _, del := range dels.DelegationResponses {
+				amount, ok := new(big.Int).SetString(del.Balance.Amount, 10)
+				if !ok {
+					return nil, fmt.Errorf("failed to parse delegation amount %s", del.Balance.Amount)
+				}
+
+				delegations = append(delegations, Delegation{
+					Controller: controller.Name,
+					Validator:  del.Delegation.ValidatorAddress,
+					Amount:     amount,
+				})
+			}
+		}
+	}
+
+	return delegations, nil
+}
+
+// fetchCosmosDelegations returns all delegations of a delegator, following pagination.
+func fetchCosmosDelegations(baseURL, delegator string, height int64) (cosmosDelegationsData, error) {
+	var (
+		res     cosmosDelegationsData
+		nextKey string
+	)
+
+	for {
+		reqURL := fmt.Sprintf("%s/cosmos/staking/v1beta1/delegations/%s?pagination.limit=1000", baseURL, delegator)
+		if nextKey != "" {
+			reqURL += "&pagination.key=" + url.QueryEscape(nextKey)
+		}
+
+		page, err := fetchCosmosDelegationsPage(reqURL, height)
+		if err != nil {
+			return cosmosDelegationsData{}, err
+		}
+
+		res.DelegationResponses = append(res.DelegationResponses, page.DelegationResponses...)
+
+		nextKey = page.Pagination.NextKey
+		if nextKey == "" {
+			return res, nil
+		}
+	}
+}
+
+func fetchCosmosDelegationsPage(url string, height int64) (cosmosDelegationsData, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Println(err)
+		return cosmosDelegationsData{}, errors.New("create get request for cosmos delegations")
+	}
+	setCosmosHeight(req, height)
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return cosmosDelegationsData{}, errors.New("get request unsuccessful for cosmos delegations")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cosmosDelegationsData{}, err
+	}
+
+	var response cosmosDelegationsData
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return cosmosDelegationsData{}, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,136 @@
+package chains
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// sharedWebsiteHosts host profiles of many unrelated operators, so sharing them is no evidence of common control.
+var sharedWebsiteHosts = map[string]bool{
+	"github.com":    true,
+	"keybase.io":    true,
+	"linktr.ee":     true,
+	"medium.com":    true,
+	"mintscan.io":   true,
+	"t.me":          true,
+	"twitter.com":   true,
+	"x.com":         true,
+	"discord.gg":    true,
+	"discord.com":   true,
+	"youtube.com":   true,
+	"linkedin.com":  true,
+	"notion.site":   true,
+	"gitbook.io":    true,
+	"substack.com":  true,
+	"instagram.com": true,
+	"facebook.com":  true,
+}
+
+// placeholderValues are commonly used to leave a description field empty.
+var placeholderValues = map[string]bool{
+	"":     true,
+	"-":    true,
+	"n/a":  true,
+	"na":   true,
+	"none": true,
+	"null": true,
+}
+
+// detectCosmosEntities groups validators that share a keybase identity, a website domain or a security contact.
+// Only groups of two or more validators are returned, along with the shared values that link them.
+func detectCosmosEntities(validators []cosmosValidator) []EntityGroup {
+	keys := make([][]string, len(validators))
+	for i, v := range validators {
+		keys[i] = cosmosEntityKeys(v)
+	}
+
+	var (
+		groups []EntityGroup
+		names  = make(map[string]bool)
+	)
+	for _, linked := range linkByKeys(keys) {
+		// Entities are merged by name, so unrelated groups with the same moniker must stay apart.
+		name := largestMoniker(validators, linked.Members)
+		if names[name] {
+			name = fmt.Sprintf("%s (%s)", name, validators[linked.Members[0]].OperatorAddress)
+		}
+		names[name] = true
+
+		group := EntityGroup{Entity: name, Evidence: linked.Evidence}
+		for _, i := range linked.Members {
+			group.Validators = append(group.Validators, validators[i].OperatorAddress)
+		}
+
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Entity < groups[j].Entity })
+
+	return groups
+}
+
+// cosmosEntityKeys returns the description values identifying the operator of a validator.
+func cosmosEntityKeys(v cosmosValidator) []string {
+	var keys []string
+
+	if identity := strings.ToUpper(strings.TrimSpace(v.Description.Identity)); !placeholderValues[strings.ToLower(identity)] {
+		keys = append(keys, "identity="+identity)
+	}
+
+	if domain := websiteDomain(v.Description.Website); domain != "" && !sharedWebsiteHosts[domain] {
+		keys = append(keys, "website="+domain)
+	}
+
+	if contact := strings.ToLower(strings.TrimSpace(v.Description.SecurityContact)); !placeholderValues[contact] {
+		keys = append(keys, "security_contact="+contact)
+	}
+
+	return keys
+}
+
+// websiteDomain returns the normalized host of a website, for example "example.com" for "https://www.Example.com/about".
+func websiteDomain(website string) string {
+	website = strings.TrimSpace(strings.ToLower(website))
+	if placeholderValues[website] {
+		return ""
+	}
+	if !strings.Contains(website, "://") {
+		website = "https://" + website
+	}
+
+	u, err := url.Parse(website)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if !strings.Contains(host, ".") {
+		return ""
+	}
+
+	return host
+}
+
+// largestMoniker returns the moniker of the validator with the most tokens among idx.
+func largestMoniker(validators []cosmosValidator, idx []int) string {
+	var (
+		best       = validators[idx[0]]
+		bestTokens = new(big.Int)
+	)
+
+	for _, i := range idx {
+		tokens, ok := new(big.Int).SetString(validators[i].Tokens, 10)
+		if ok && tokens.Cmp(bestTokens) > 0 {
+			best, bestTokens = validators[i], tokens
+		}
+	}
+
+	if best.Description.Moniker == "" {
+		return best.OperatorAddress
+	}
+
+	return best.Description.Moniker
+}
@@ -0,0 +1,65 @@
+package chains
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchAllValidatorData(t *testing.T) {
+	tests := []struct {
+		name string
+		// keys[i] is the next key returned with page i, which is requested with keys[i-1].
+		keys    []string
+		status  int
+		want    int
+		wantErr bool
+	}{
+		{name: "single page", keys: []string{""}, want: 1},
+		{name: "three pages", keys: []string{"a", "b", ""}, want: 3},
+		{name: "repeated key", keys: []string{"a", "b", "a"}, wantErr: true},
+		{name: "error status", keys: []string{""}, status: http.StatusBadGateway, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, `{"validators":[]}`)
+					return
+				}
+
+				page := 0
+				if key := r.URL.Query().Get("pagination.key"); key != "" {
+					// After a repeated key, the pages are served again from the first occurrence.
+					for page < len(tt.keys) && tt.keys[page] != key {
+						page++
+					}
+					page++
+				}
+				if page >= len(tt.keys) {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprintf(w, `{"validators":[{"operator_address":"val%d"}],"pagination":{"next_key":%q}}`, page, tt.keys[page])
+			}))
+			defer server.Close()
+
+			res, err := fetchAllValidatorData(server.URL+"/cosmos/staking/v1beta1/validators", 0)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d validators", len(res.Validators))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Validators) != tt.want {
+				t.Errorf("got %d validators, want %d", len(res.Validators), tt.want)
+			}
+		})
+	}
+}
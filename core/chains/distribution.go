@@ -0,0 +1,152 @@
+package chains
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
+)
+
+// Validator is a single participant in a chain's consensus along with its voting power.
+// Depending on the chain this may be a validator, a stake pool, a representative or an indexer.
+type Validator struct {
+	Address string
+	// Name is the display name of the validator, if known.
+	Name        string
+	VotingPower *big.Int
+}
+
+// Distribution is the voting power distribution of a chain as returned by its fetcher.
+type Distribution struct {
+	Validators []Validator
+	// TotalVotingPower is set when the chain reports its total voting power separately,
+	// for example the bonded pool of a Cosmos SDK chain. The sum of the validators is used otherwise.
+	TotalVotingPower *big.Int
+	// ThresholdPercent is the share of the total voting power a coalition must exceed.
+	// utils.THRESHOLD_PERCENT is used when zero.
+	ThresholdPercent float64
+	// ThresholdInclusive makes a coalition holding exactly the threshold share control the chain as well.
+	ThresholdInclusive bool
+	// EntityGroups are validators the fetcher detected to be run by the same entity.
+	EntityGroups []EntityGroup
+	// Delegations is the stake known controllers, such as liquid staking protocols, delegated to validators.
+	Delegations []Delegation
+	// Candidates is the full candidate set of chains where Validators is only the elected active set.
+	Candidates []Validator
+	// NextEpoch is the validator set taking effect at the next epoch, for chains that publish it in advance.
+	NextEpoch []Validator
+	// Alternatives are other weightings of the chain's participants, such as their share of block
+	// production, each reported as an additional coefficient under its name.
+	Alternatives map[string]Distribution
+	// Groupings map validators to infrastructure, such as their data center or country, by the name of
+	// the grouping. The coefficient over each grouping is reported as an additional coefficient.
+	Groupings map[string]EntityMapping
+	// NetworkAddresses are the IP addresses or host names of the validators, if the fetcher knows them.
+	// They are mapped to infrastructure groupings with the local IP databases.
+	NetworkAddresses map[string]string
+	// Warnings flag validators at risk of dropping out of consensus, such as large validators whose keys expire soon.
+	Warnings []Warning
+	// Committee describes the committees sampled by stake each round, for chains using sortition.
+	Committee *Committee
+}
+
+// Warning flags a risk concerning a single validator.
+type Warning struct {
+	Validator string
+	Message   string
+}
+
+// Sensitivity describes how much voting power must shift to move a chain's Nakamoto coefficient by one.
+type Sensitivity struct {
+	// Coalition is the smallest set of validators whose voting power exceeds the threshold,
+	// known as the superminority on Solana.
+	Coalition []string
+	// Margin is the voting power of the controlling coalition above the threshold.
+	Margin *big.Int
+	// MarginPercent is Margin as a percentage of the total voting power.
+	MarginPercent float64
+	// RaiseStake must leave the validators in RaiseFrom for the coefficient to increase by one.
+	// It is nil when the coefficient cannot increase.
+	RaiseStake *big.Int
+	RaiseFrom  []string
+	// LowerStake must move into the validators in LowerTo for the coefficient to decrease by one.
+	// It is nil when the coefficient cannot decrease.
+	LowerStake *big.Int
+	LowerTo    []string
+}
+
+// Calculate returns the Nakamoto coefficient of the distribution and its sensitivity.
+func (d Distribution) Calculate() (int, Sensitivity, error) {
+	if len(d.Validators) == 0 {
+		return 0, Sensitivity{}, fmt.Errorf("no validators")
+	}
+
+	validators := d.sorted()
+	votingPowers := make([]*big.Int, 0, len(validators))
+	for _, v := range validators {
+		votingPowers = append(votingPowers, v.VotingPower)
+	}
+
+	total := d.TotalVotingPower
+	if total == nil {
+		total = utils.CalculateTotalVotingPowerBigInt(votingPowers)
+	}
+	if total.Sign() == 0 {
+		return 0, Sensitivity{}, fmt.Errorf("total voting power is zero")
+	}
+
+	threshold := d.ThresholdPercent
+	if threshold == 0 {
+		threshold = utils.THRESHOLD_PERCENT
+	}
+
+	res := utils.CalcNakamotoSensitivity(total, votingPowers, threshold, d.ThresholdInclusive)
+
+	marginPercent, _ := new(big.Rat).SetFrac(new(big.Int).Mul(res.Margin, big.NewInt(100)), total).Float64()
+
+	coalition := make([]int, res.Coefficient)
+	for i := range coalition {
+		coalition[i] = i
+	}
+
+	return res.Coefficient, Sensitivity{
+		Coalition:     addresses(validators, coalition),
+		Margin:        res.Margin,
+		MarginPercent: marginPercent,
+		RaiseStake:    res.RaiseStake,
+		RaiseFrom:     addresses(validators, res.RaiseFrom),
+		LowerStake:    res.LowerStake,
+		LowerTo:       addresses(validators, res.LowerTo),
+	}, nil
+}
+
+// sorted returns a copy of the validators sorted by voting power in descending order.
+// Validators without an address are named after their position in the fetched list.
+func (d Distribution) sorted() []Validator {
+	validators := make([]Validator, len(d.Validators))
+	for i, v := range d.Validators {
+		if v.Address == "" {
+			v.Address = fmt.Sprintf("#%d", i+1)
+		}
+		if v.VotingPower == nil {
+			v.VotingPower = big.NewInt(0)
+		}
+		validators[i] = v
+	}
+
+	sort.SliceStable(validators, func(i, j int) bool {
+		return validators[i].VotingPower.Cmp(validators[j].VotingPower) > 0
+	})
+
+	return validators
+}
+
+func addresses(validators []Validator, indices []int) []string {
+	var res []string
+	for _, i := range indices {
+		res = append(res, validators[i].Address)
+	}
+
+	return res
+}
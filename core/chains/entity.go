@@ -0,0 +1,216 @@
+package chains
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// EntityMapping maps validator addresses to the entity (operator, company, person) running them.
+type EntityMapping map[string]string
+
+// EntityGroup is a set of validators detected to be run by the same entity, along with the evidence for it.
+type EntityGroup struct {
+	Entity     string
+	Validators []string
+	Evidence   []string
+}
+
+// linkedItems are items linked by sharing keys, along with the shared keys as evidence.
+type linkedItems struct {
+	Members  []int
+	Evidence []string
+}
+
+// linkByKeys groups the items that share at least one key, directly or through other items, where keys[i]
+// are the keys of item i. Only groups of two or more items are returned, in the order of their first item.
+func linkByKeys(keys [][]string) []linkedItems {
+	parent := make([]int, len(keys))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// Link every item to the first item sharing one of its keys.
+	firstByKey := make(map[string]int)
+	sharedBy := make(map[string]int)
+	for i, itemKeys := range keys {
+		for _, key := range itemKeys {
+			sharedBy[key]++
+			if j, ok := firstByKey[key]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				firstByKey[key] = i
+			}
+		}
+	}
+
+	var (
+		roots   []int
+		members = make(map[int][]int)
+	)
+	for i := range keys {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	evidence := make(map[int][]string)
+	for key, n := range sharedBy {
+		if n > 1 {
+			root := find(firstByKey[key])
+			evidence[root] = append(evidence[root], fmt.Sprintf("%s shared by %d validators", key, n))
+		}
+	}
+
+	var linked []linkedItems
+	for _, root := range roots {
+		if len(members[root]) < 2 {
+			continue
+		}
+
+		sort.Strings(evidence[root])
+		linked = append(linked, linkedItems{Members: members[root], Evidence: evidence[root]})
+	}
+
+	return linked
+}
+
+// entityFile is the on-disk format of an entity mapping:
+//
+//	{"entities": [{"entity": "Operator", "addresses": ["addr1", "addr2"]}]}
+type entityFile struct {
+	Entities []struct {
+		Entity    string   `json:"entity"`
+		Addresses []string `json:"addresses"`
+		// Representatives is accepted for compatibility with the nanocharts.info format.
+		Representatives []string `json:"representatives"`
+	} `json:"entities"`
+}
+
+// entityHeadlines are the chains whose coefficient has always been published per entity. It stays so, and the
+// per-validator coefficient is reported under the given name in the chain's metrics.
+var entityHeadlines = map[Token]string{
+	XNO: "representative",
+}
+
+// defaultEntityMappings are used for chains whose entity mapping is published by a third party.
+var defaultEntityMappings = map[Token]string{
+	XNO: "https://nanocharts.info/data/entities.json",
+}
+
+// entityMappingSource returns the path or URL of the entity mapping for the given chain, if any.
+// It is taken from ENTITY_MAPPING_<TOKEN>, then <ENTITY_MAPPINGS_DIR>/<token>.json, then the defaults.
+func entityMappingSource(token Token) string {
+	if source := configSource(token, "ENTITY_MAPPING_", "ENTITY_MAPPINGS_DIR", "entities"); source != "" {
+		return source
+	}
+
+	return defaultEntityMappings[token]
+}
+
+// LoadEntityMapping loads an entity mapping from a file path or an http(s) URL.
+func LoadEntityMapping(source string) (EntityMapping, error) {
+	body, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var file entityFile
+	if err := json.Unmarshal(body, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse entity mapping %s: %w", source, err)
+	}
+
+	mapping := make(EntityMapping)
+	for _, entity := range file.Entities {
+		for _, addr := range append(entity.Addresses, entity.Representatives...) {
+			mapping[addr] = entity.Entity
+		}
+	}
+
+	return mapping, nil
+}
+
+// entityAddressPrefix prefixes the address of a merged entity, so that it never collides with the address
+// of a validator missing from the mapping.
+const entityAddressPrefix = "entity:"
+
+// GroupByEntity returns a distribution where validators run by the same entity are merged into one, whose
+// address is the entity prefixed with entityAddressPrefix and whose name is the entity.
+// Validators missing from the mapping are treated as their own entity and keep their address and name.
+func (d Distribution) GroupByEntity(mapping EntityMapping) Distribution {
+	var (
+		grouped = d
+		index   = make(map[string]int)
+	)
+
+	grouped.Validators = nil
+	for _, v := range d.Validators {
+		name, ok := mapping[v.Address]
+		if !ok || v.Address == "" {
+			grouped.Validators = append(grouped.Validators, v)
+			continue
+		}
+
+		i, ok := index[name]
+		if !ok {
+			index[name] = len(grouped.Validators)
+			grouped.Validators = append(grouped.Validators, Validator{
+				Address:     entityAddressPrefix + name,
+				Name:        name,
+				VotingPower: new(big.Int),
+			})
+			i = index[name]
+		}
+		if v.VotingPower != nil {
+			grouped.Validators[i].VotingPower.Add(grouped.Validators[i].VotingPower, v.VotingPower)
+		}
+	}
+
+	return grouped
+}
+
+// entityValue returns the entity-level Nakamoto coefficient of the chain or zero if no entities are known.
+func entityValue(token Token, dist Distribution) (int, error) {
+	mapping, err := entityMapping(token, dist)
+	if err != nil || len(mapping) == 0 {
+		return 0, err
+	}
+
+	val, _, err := dist.GroupByEntity(mapping).Calculate()
+
+	return val, err
+}
+
+// entityMapping returns the entities of the chain's validators. Entities from the chain's mapping file take
+// precedence over the ones detected by its fetcher.
+func entityMapping(token Token, dist Distribution) (EntityMapping, error) {
+	mapping := make(EntityMapping)
+	for _, group := range dist.EntityGroups {
+		for _, addr := range group.Validators {
+			mapping[addr] = group.Entity
+		}
+	}
+
+	if source := entityMappingSource(token); source != "" {
+		loaded, err := LoadEntityMapping(source)
+		if err != nil {
+			return nil, err
+		}
+		for addr, entity := range loaded {
+			mapping[addr] = entity
+		}
+	}
+
+	return mapping, nil
+}
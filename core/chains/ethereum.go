@@ -0,0 +1,133 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// ethereumBeaconURL is a public beacon API used when BEACON_URL_ETH is not set.
+	ethereumBeaconURL = "https://ethereum-beacon-api.publicnode.com"
+	// ratedPageSize is the number of validator mappings requested per page from Rated.
+	ratedPageSize = 1000
+)
+
+type ratedMappingsResponse struct {
+	Data []struct {
+		ValidatorPubkey string `json:"validatorPubkey"`
+		NodeOperator    string `json:"nodeOperator"`
+	} `json:"data"`
+	Next string `json:"next"`
+}
+
+// Ethereum returns the effective balances of the active Ethereum validators grouped into operators.
+// When RATED_API_KEY is set, the node operators known to Rated (https://rated.network) label the validators.
+func Ethereum() (Distribution, error) {
+	var labels EntityMapping
+	if apiKey := os.Getenv("RATED_API_KEY"); apiKey != "" {
+		var err error
+		labels, err = fetchRatedOperators(apiKey)
+		if err != nil {
+			log.Printf("Failed to fetch Rated operators: %v", err)
+		}
+	}
+
+	return FetchBeaconValidators(ETH, beaconURL(ETH, ethereumBeaconURL), labels)
+}
+
+// fetchRatedOperators returns the node operator of each validator pubkey known to Rated, following pagination.
+// The API is read from RATED_API_URL, https://api.rated.network by default.
+func fetchRatedOperators(apiKey string) (EntityMapping, error) {
+	apiURL := os.Getenv("RATED_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.rated.network"
+	}
+	apiURL = strings.TrimSuffix(apiURL, "/")
+
+	var (
+		operators = make(EntityMapping)
+		pageURL   = fmt.Sprintf("%s/v0/eth/validators/mappings?size=%d", apiURL, ratedPageSize)
+	)
+	for pageURL != "" {
+		page, err := fetchRatedMappingsPage(pageURL, apiKey)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mapping := range page.Data {
+			if mapping.NodeOperator != "" {
+				operators[mapping.ValidatorPubkey] = mapping.NodeOperator
+			}
+		}
+
+		pageURL, err = ratedNextURL(apiURL, page.Next)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return operators, nil
+}
+
+// ratedNextURL resolves the link to the next page, which Rated returns relative to the API.
+func ratedNextURL(apiURL, next string) (string, error) {
+	if next == "" {
+		return "", nil
+	}
+
+	base, err := url.Parse(apiURL + "/")
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("invalid next page %q", next)
+	}
+
+	return base.ResolveReference(ref).String(), nil
+}
+
+func fetchRatedMappingsPage(url, apiKey string) (ratedMappingsResponse, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Println(err)
+		return ratedMappingsResponse{}, errors.New("create get request for rated mappings")
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("X-Rated-Network", "mainnet")
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return ratedMappingsResponse{}, errors.New("get request unsuccessful for rated mappings")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ratedMappingsResponse{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ratedMappingsResponse{}, fmt.Errorf("rated mappings request failed: %d", resp.StatusCode)
+	}
+
+	var response ratedMappingsResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return ratedMappingsResponse{}, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,147 @@
+package chains
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// abiWordLen is the length of a word in the Solidity ABI encoding.
+const abiWordLen = 32
+
+// evmRPCURL returns the JSON-RPC endpoint from EVM_RPC_<TOKEN>, or defaultURL.
+func evmRPCURL(token Token, defaultURL string) string {
+	if url := os.Getenv("EVM_RPC_" + string(token)); url != "" {
+		return url
+	}
+
+	return defaultURL
+}
+
+// evmContract reads a contract through eth_call. All calls are made at the same block.
+type evmContract struct {
+	rpcURL  string
+	block   string
+	address string
+}
+
+// evmLatestBlock returns the latest block number of an EVM chain, as a hex quantity.
+func evmLatestBlock(rpcURL string) (string, error) {
+	var block string
+	if err := jsonRPC(rpcURL, "eth_blockNumber", []interface{}{}, &block); err != nil {
+		return "", fmt.Errorf("failed to fetch block number: %w", err)
+	}
+
+	return block, nil
+}
+
+// call calls a view function of the contract with static arguments and returns the ABI encoded result.
+func (c evmContract) call(signature string, args ...[]byte) ([]byte, error) {
+	data := evmSelector(signature)
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+
+	var result string
+	params := []interface{}{
+		map[string]string{"to": c.address, "data": "0x" + hex.EncodeToString(data)},
+		c.block,
+	}
+	if err := jsonRPC(c.rpcURL, "eth_call", params, &result); err != nil {
+		return nil, fmt.Errorf("%s on %s: %w", signature, c.address, err)
+	}
+
+	res, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%s on %s: invalid result %q", signature, c.address, result)
+	}
+
+	return res, nil
+}
+
+// evmSelector returns the function selector of a signature such as "balanceOf(address)".
+func evmSelector(signature string) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(signature))
+
+	return h.Sum(nil)[:4]
+}
+
+// abiUint encodes an unsigned integer argument.
+func abiUint(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, abiWordLen))
+}
+
+// abiAddressArg encodes an address argument.
+func abiAddressArg(address string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil || len(b) != 20 {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+
+	return append(make([]byte, abiWordLen-len(b)), b...), nil
+}
+
+// abiWord returns the i-th word of an ABI encoded result.
+func abiWord(data []byte, i int) ([]byte, error) {
+	if len(data) < (i+1)*abiWordLen {
+		return nil, fmt.Errorf("result too short for word %d", i)
+	}
+
+	return data[i*abiWordLen : (i+1)*abiWordLen], nil
+}
+
+// abiUintAt decodes the unsigned integer in the i-th word.
+func abiUintAt(data []byte, i int) (*big.Int, error) {
+	word, err := abiWord(data, i)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(word), nil
+}
+
+// abiAddressAt decodes the address in the i-th word.
+func abiAddressAt(data []byte, i int) (string, error) {
+	word, err := abiWord(data, i)
+	if err != nil {
+		return "", err
+	}
+
+	return "0x" + hex.EncodeToString(word[abiWordLen-20:]), nil
+}
+
+// abiAddressArrayAt decodes the dynamic address array whose offset is in the i-th word.
+func abiAddressArrayAt(data []byte, i int) ([]string, error) {
+	offset, err := abiUintAt(data, i)
+	if err != nil {
+		return nil, err
+	}
+	if !offset.IsInt64() || offset.Int64()%abiWordLen != 0 {
+		return nil, fmt.Errorf("invalid array offset %s", offset)
+	}
+	start := int(offset.Int64() / abiWordLen)
+
+	length, err := abiUintAt(data, start)
+	if err != nil {
+		return nil, err
+	}
+	if !length.IsInt64() || length.Int64() > int64(len(data)/abiWordLen) {
+		return nil, fmt.Errorf("invalid array length %s", length)
+	}
+
+	addresses := make([]string, 0, length.Int64())
+	for j := 0; j < int(length.Int64()); j++ {
+		address, err := abiAddressAt(data, start+1+j)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
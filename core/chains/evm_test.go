@@ -0,0 +1,360 @@
+package chains
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const evmTestBlock = "0x1312d00"
+
+// evmCall answers an eth_call to a contract function with the ABI encoded result, given the call's arguments.
+type evmCall func(args []byte) ([]byte, error)
+
+// errEVMRevert is answered by a call standing in for a reverted one.
+var errEVMRevert = errors.New("execution reverted")
+
+// evmStandIn serves eth_blockNumber and eth_call for the given calls, keyed by contract address and signature.
+// Calls at any other block than evmTestBlock fail the test.
+func evmStandIn(t *testing.T, calls map[string]evmCall) string {
+	bySelector := make(map[string]evmCall)
+	for key, call := range calls {
+		address, signature, _ := strings.Cut(key, " ")
+		bySelector[strings.ToLower(address)+hex.EncodeToString(evmSelector(signature))] = call
+	}
+
+	server := newJSONRPCStandIn(t, map[string]rpcHandler{
+		"eth_blockNumber": func(json.RawMessage) (interface{}, error) {
+			return evmTestBlock, nil
+		},
+		"eth_call": func(raw json.RawMessage) (interface{}, error) {
+			var (
+				tx struct {
+					To   string `json:"to"`
+					Data string `json:"data"`
+				}
+				block string
+			)
+			if err := json.Unmarshal(raw, &[]interface{}{&tx, &block}); err != nil {
+				return nil, err
+			}
+			if block != evmTestBlock {
+				t.Errorf("eth_call at block %s, want %s", block, evmTestBlock)
+			}
+
+			data := strings.TrimPrefix(tx.Data, "0x")
+			call, ok := bySelector[strings.ToLower(tx.To)+data[:8]]
+			if !ok {
+				// Calls to functions a contract does not have return no data.
+				return "0x", nil
+			}
+
+			args, _ := hex.DecodeString(data[8:])
+			res, err := call(args)
+			if err != nil {
+				return nil, err
+			}
+			return "0x" + hex.EncodeToString(res), nil
+		},
+	})
+
+	return server.URL
+}
+
+// abiEncode concatenates words given as integers, addresses or address arrays into an ABI encoded result.
+// Address arrays are encoded as dynamic tail data after the head words.
+func abiEncode(values ...interface{}) []byte {
+	var head, tail []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case int:
+			head = append(head, abiUint(big.NewInt(int64(v)))...)
+		case *big.Int:
+			head = append(head, abiUint(v)...)
+		case string:
+			arg, _ := abiAddressArg(v)
+			head = append(head, arg...)
+		case []string:
+			head = append(head, abiUint(big.NewInt(int64(len(values)*abiWordLen+len(tail))))...)
+			tail = append(tail, abiUint(big.NewInt(int64(len(v))))...)
+			for _, address := range v {
+				arg, _ := abiAddressArg(address)
+				tail = append(tail, arg...)
+			}
+		}
+	}
+
+	return append(head, tail...)
+}
+
+func evmAddress(b byte) string {
+	return "0x" + strings.Repeat(hex.EncodeToString([]byte{b}), 20)
+}
+
+func TestEVMSelector(t *testing.T) {
+	tests := []struct {
+		signature string
+		want      string
+	}{
+		{signature: "transfer(address,uint256)", want: "a9059cbb"},
+		{signature: "balanceOf(address)", want: "70a08231"},
+		{signature: "totalSupply()", want: "18160ddd"},
+	}
+
+	for _, tt := range tests {
+		if got := hex.EncodeToString(evmSelector(tt.signature)); got != tt.want {
+			t.Errorf("evmSelector(%s) = %s, want %s", tt.signature, got, tt.want)
+		}
+	}
+}
+
+func TestABIAddressArrayAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []string
+		wantErr bool
+	}{
+		{name: "two addresses", data: abiEncode([]string{evmAddress(1), evmAddress(2)}), want: []string{evmAddress(1), evmAddress(2)}},
+		{name: "empty array", data: abiEncode([]string{}), want: []string{}},
+		{name: "empty result", data: nil, wantErr: true},
+		{name: "unaligned offset", data: abiEncode(33, 0), wantErr: true},
+		{name: "length beyond data", data: abiEncode(32, 5, evmAddress(1)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := abiAddressArrayAt(tt.data, 0)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, expected an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBSC(t *testing.T) {
+	operators := []string{evmAddress(0xa1), evmAddress(0xa2), evmAddress(0xa3)}
+	credits := []string{evmAddress(0xc1), evmAddress(0xc2), evmAddress(0xc3)}
+	consensus := map[string]string{evmAddress(0xe1): operators[0], evmAddress(0xe3): operators[2]}
+
+	stakeHubCalls := func() map[string]evmCall {
+		calls := map[string]evmCall{
+			bscStakeHub + " getValidators(uint256,uint256)": func(args []byte) ([]byte, error) {
+				if offset, _ := abiUintAt(args, 0); offset.Sign() != 0 {
+					return abiEncode([]string{}, []string{}, len(operators)), nil
+				}
+				return abiEncode(operators, credits, len(operators)), nil
+			},
+			bscStakeHub + " consensusToOperator(address)": func(args []byte) ([]byte, error) {
+				addr, _ := abiAddressAt(args, 0)
+				return abiEncode(consensus[addr]), nil
+			},
+			bscValidatorSet + " getMiningValidators()": func([]byte) ([]byte, error) {
+				return abiEncode([]string{evmAddress(0xe3), evmAddress(0xe1)}, 2), nil
+			},
+		}
+		for i, credit := range credits {
+			pooled := new(big.Int).Mul(big.NewInt(int64(i+1)), big.NewInt(1e18))
+			calls[credit+" totalPooledBNB()"] = func([]byte) ([]byte, error) { return abiEncode(pooled), nil }
+		}
+		return calls
+	}
+
+	tests := []struct {
+		name       string
+		override   map[string]evmCall
+		want       map[string]int64 // stake in BNB by operator
+		candidates int
+		wantErr    bool
+	}{
+		{
+			name:       "mining validators",
+			want:       map[string]int64{operators[0]: 1, operators[2]: 3},
+			candidates: 3,
+		},
+		{
+			name: "no mining validators",
+			override: map[string]evmCall{bscValidatorSet + " getMiningValidators()": func([]byte) ([]byte, error) {
+				return abiEncode([]string{}, 0), nil
+			}},
+			wantErr: true,
+		},
+		{
+			name: "reverted stake credit",
+			override: map[string]evmCall{credits[1] + " totalPooledBNB()": func([]byte) ([]byte, error) {
+				return nil, errEVMRevert
+			}},
+			wantErr: true,
+		},
+		{
+			name: "empty stake credit result",
+			override: map[string]evmCall{credits[1] + " totalPooledBNB()": func([]byte) ([]byte, error) {
+				return nil, nil
+			}},
+			wantErr: true,
+		},
+		{
+			name: "truncated validator list",
+			override: map[string]evmCall{bscStakeHub + " getValidators(uint256,uint256)": func([]byte) ([]byte, error) {
+				return abiEncode(operators, credits[:2], len(operators)), nil
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := stakeHubCalls()
+			for key, call := range tt.override {
+				calls[key] = call
+			}
+			t.Setenv("EVM_RPC_BNB", evmStandIn(t, calls))
+
+			dist, err := BSC()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkEVMStakes(t, dist.Validators, tt.want)
+			if len(dist.Candidates) != tt.candidates {
+				t.Errorf("got %d candidates, want %d", len(dist.Candidates), tt.candidates)
+			}
+		})
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	// polygonValidator encodes the StakeManager's Validator struct, with the stake in MATIC.
+	polygonValidator := func(amount, delegated int64, deactivationEpoch, status int, signer string) evmCall {
+		return func([]byte) ([]byte, error) {
+			ether := big.NewInt(1e18)
+			return abiEncode(new(big.Int).Mul(big.NewInt(amount), ether), 0, 1, deactivationEpoch, 0, signer,
+				evmAddress(0xcc), status, 0, 0, 0, new(big.Int).Mul(big.NewInt(delegated), ether), 0), nil
+		}
+	}
+
+	validators := map[int64]evmCall{
+		1: polygonValidator(10, 5, 0, polygonStatusActive, evmAddress(0x01)),
+		2: polygonValidator(20, 0, 0, 3, evmAddress(0x02)),                     // unstaked
+		3: polygonValidator(30, 10, 90, polygonStatusActive, evmAddress(0x03)), // deactivated at epoch 90
+		4: polygonValidator(40, 0, 200, polygonStatusActive, evmAddress(0x04)), // unbonding until epoch 200
+	}
+
+	baseCalls := func() map[string]evmCall {
+		return map[string]evmCall{
+			polygonStakeManager + " NFTCounter()":   func([]byte) ([]byte, error) { return abiEncode(5), nil },
+			polygonStakeManager + " currentEpoch()": func([]byte) ([]byte, error) { return abiEncode(100), nil },
+			polygonStakeManager + " currentValidatorSetTotalStake()": func([]byte) ([]byte, error) {
+				return abiEncode(new(big.Int).Mul(big.NewInt(55), big.NewInt(1e18))), nil
+			},
+			polygonStakeManager + " validators(uint256)": func(args []byte) ([]byte, error) {
+				id, _ := abiUintAt(args, 0)
+				if call, ok := validators[id.Int64()]; ok {
+					return call(nil)
+				}
+				return nil, errEVMRevert
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		override map[string]evmCall
+		want     map[string]int64
+		wantErr  bool
+	}{
+		{
+			name: "active validators",
+			want: map[string]int64{evmAddress(0x01): 15, evmAddress(0x04): 40},
+		},
+		{
+			name: "reverted validator",
+			override: map[string]evmCall{polygonStakeManager + " NFTCounter()": func([]byte) ([]byte, error) {
+				return abiEncode(6), nil
+			}},
+			wantErr: true,
+		},
+		{
+			name: "empty epoch result",
+			override: map[string]evmCall{polygonStakeManager + " currentEpoch()": func([]byte) ([]byte, error) {
+				return nil, nil
+			}},
+			wantErr: true,
+		},
+		{
+			name: "truncated validator struct",
+			override: map[string]evmCall{polygonStakeManager + " validators(uint256)": func([]byte) ([]byte, error) {
+				return abiEncode(1, 2, 3), nil
+			}},
+			wantErr: true,
+		},
+		{
+			// An active validator whose struct ends before the delegated amount.
+			name: "validator struct without delegated amount",
+			override: map[string]evmCall{polygonStakeManager + " validators(uint256)": func([]byte) ([]byte, error) {
+				return abiEncode(10, 0, 1, 0, 0, evmAddress(0x01), evmAddress(0xcc), polygonStatusActive, 0), nil
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := baseCalls()
+			for key, call := range tt.override {
+				calls[key] = call
+			}
+			t.Setenv("EVM_RPC_MATIC", evmStandIn(t, calls))
+
+			dist, err := Polygon()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkEVMStakes(t, dist.Validators, tt.want)
+		})
+	}
+}
+
+// checkEVMStakes checks the validators against their expected stake in whole tokens of 18 decimals.
+func checkEVMStakes(t *testing.T, validators []Validator, want map[string]int64) {
+	t.Helper()
+
+	if len(validators) != len(want) {
+		t.Errorf("got %d validators, want %d", len(validators), len(want))
+	}
+	for _, v := range validators {
+		stake, ok := want[v.Address]
+		if !ok {
+			t.Errorf("unexpected validator %s", v.Address)
+			continue
+		}
+		if wantStake := new(big.Int).Mul(big.NewInt(stake), big.NewInt(1e18)); v.VotingPower.Cmp(wantStake) != 0 {
+			t.Errorf("stake of %s = %s, want %s", v.Address, v.VotingPower, wantStake)
+		}
+	}
+}
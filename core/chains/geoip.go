@@ -0,0 +1,298 @@
+package chains
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// geoDatabase maps IP networks to records, loaded from a MaxMind DB (.mmdb) file or from a CSV file whose
+// first column is the network in CIDR notation, such as GeoLite2-ASN-Blocks-IPv4.csv.
+type geoDatabase struct {
+	mmdb *mmdbReader
+	// networks are the CSV records keyed by network, for example "192.0.2.0/24".
+	networks map[string][]string
+}
+
+var (
+	geoMu        sync.Mutex
+	geoDatabases = make(map[string]*geoDatabase)
+	geoHosts     = make(map[string]map[string]net.IP)
+)
+
+// loadGeoDatabase returns the database at path, loading it on first use.
+func loadGeoDatabase(path string) (*geoDatabase, error) {
+	geoMu.Lock()
+	defer geoMu.Unlock()
+
+	if db, ok := geoDatabases[path]; ok {
+		return db, nil
+	}
+
+	db := &geoDatabase{}
+	if strings.HasSuffix(path, ".mmdb") {
+		mmdb, err := openMMDB(path)
+		if err != nil {
+			return nil, err
+		}
+		db.mmdb = mmdb
+	} else {
+		networks, err := readGeoCSV(path)
+		if err != nil {
+			return nil, err
+		}
+		db.networks = networks
+	}
+
+	geoDatabases[path] = db
+
+	return db, nil
+}
+
+// readGeoCSV reads a CSV database, skipping rows whose first column is not a network, such as the header.
+func readGeoCSV(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	networks := make(map[string][]string)
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return networks, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		_, network, err := net.ParseCIDR(strings.TrimSpace(row[0]))
+		if err != nil {
+			continue
+		}
+		networks[network.String()] = row[1:]
+	}
+}
+
+// loadGeoHosts returns the host name to IP mapping of the hosts file at path, loading it on first use.
+func loadGeoHosts(path string) (map[string]net.IP, error) {
+	geoMu.Lock()
+	defer geoMu.Unlock()
+
+	if hosts, ok := geoHosts[path]; ok {
+		return hosts, nil
+	}
+
+	hosts, err := readGeoHosts(path)
+	if err != nil {
+		return nil, err
+	}
+	geoHosts[path] = hosts
+
+	return hosts, nil
+}
+
+// readGeoHosts reads a file in the format of /etc/hosts: an IP address followed by the host names resolving
+// to it on each line, with comments starting with #. The first address listed for a host name is used.
+func readGeoHosts(path string) (map[string]net.IP, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hosts := make(map[string]net.IP)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+		for _, host := range fields[1:] {
+			host = strings.ToLower(strings.TrimSuffix(host, "."))
+			if _, ok := hosts[host]; !ok {
+				hosts[host] = ip
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return hosts, nil
+}
+
+// lookupMMDB returns the MaxMind DB record of ip, or nil.
+func (db *geoDatabase) lookupMMDB(ip net.IP) map[string]interface{} {
+	record, err := db.mmdb.lookup(ip)
+	if err != nil {
+		log.Printf("Failed to look up %s: %v", ip, err)
+	}
+
+	return record
+}
+
+// lookupCSV returns the CSV row of the most specific network containing ip, without the network column, or nil.
+func (db *geoDatabase) lookupCSV(ip net.IP) []string {
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+
+	for ones := bits; ones >= 0; ones-- {
+		network := net.IPNet{IP: ip.Mask(net.CIDRMask(ones, bits)), Mask: net.CIDRMask(ones, bits)}
+		if row, ok := db.networks[network.String()]; ok {
+			return row
+		}
+	}
+
+	return nil
+}
+
+// ipASN returns the autonomous system number and organization of ip. CSV databases have the
+// columns network,autonomous_system_number,autonomous_system_organization.
+func (db *geoDatabase) ipASN(ip net.IP) (asn, organization string) {
+	if db.mmdb != nil {
+		record := db.lookupMMDB(ip)
+		if n, ok := record["autonomous_system_number"].(uint64); ok {
+			asn = fmt.Sprintf("AS%d", n)
+		}
+		organization, _ = record["autonomous_system_organization"].(string)
+		return asn, organization
+	}
+
+	row := db.lookupCSV(ip)
+	if len(row) > 0 && row[0] != "" {
+		asn = "AS" + strings.TrimPrefix(strings.ToUpper(row[0]), "AS")
+	}
+	if len(row) > 1 {
+		organization = row[1]
+	}
+
+	return asn, organization
+}
+
+// ipCountry returns the ISO country code of ip. CSV databases have the columns network,country_code.
+func (db *geoDatabase) ipCountry(ip net.IP) string {
+	if db.mmdb != nil {
+		record := db.lookupMMDB(ip)
+		for _, key := range []string{"country", "registered_country"} {
+			if country, ok := record[key].(map[string]interface{}); ok {
+				if code, ok := country["iso_code"].(string); ok {
+					return code
+				}
+			}
+		}
+		return ""
+	}
+
+	if row := db.lookupCSV(ip); len(row) > 0 {
+		return strings.ToUpper(row[0])
+	}
+
+	return ""
+}
+
+// infrastructureGroupings maps validators to their autonomous system, hosting provider and country, given
+// the network address of each validator, using the databases in GEOIP_ASN_DB and GEOIP_COUNTRY_DB.
+// Host names are only resolved through the hosts file in GEOIP_HOSTS_FILE, so nothing is looked up over the
+// network; validators with other host names are left out. It returns nil when no database is configured.
+func infrastructureGroupings(networkAddresses map[string]string) map[string]EntityMapping {
+	asnPath, countryPath := os.Getenv("GEOIP_ASN_DB"), os.Getenv("GEOIP_COUNTRY_DB")
+	if (asnPath == "" && countryPath == "") || len(networkAddresses) == 0 {
+		return nil
+	}
+
+	var (
+		asnDB, countryDB *geoDatabase
+		err              error
+		groupings        = make(map[string]EntityMapping)
+	)
+	if asnPath != "" {
+		if asnDB, err = loadGeoDatabase(asnPath); err != nil {
+			log.Printf("Failed to load ASN database: %v", err)
+		} else {
+			groupings["asn"] = make(EntityMapping)
+			groupings["hosting_provider"] = make(EntityMapping)
+		}
+	}
+	if countryPath != "" {
+		if countryDB, err = loadGeoDatabase(countryPath); err != nil {
+			log.Printf("Failed to load country database: %v", err)
+		} else {
+			groupings["country"] = make(EntityMapping)
+		}
+	}
+
+	var hosts map[string]net.IP
+	if hostsPath := os.Getenv("GEOIP_HOSTS_FILE"); hostsPath != "" {
+		if hosts, err = loadGeoHosts(hostsPath); err != nil {
+			log.Printf("Failed to load hosts file: %v", err)
+		}
+	}
+
+	for validator, host := range networkAddresses {
+		ip := resolveHost(host, hosts)
+		if ip == nil {
+			continue
+		}
+
+		if asnDB != nil {
+			asn, organization := asnDB.ipASN(ip)
+			if asn != "" {
+				groupings["asn"][validator] = asn
+			}
+			if organization != "" {
+				groupings["hosting_provider"][validator] = organization
+			}
+		}
+		if countryDB != nil {
+			if country := countryDB.ipCountry(ip); country != "" {
+				groupings["country"][validator] = country
+			}
+		}
+	}
+
+	return groupings
+}
+
+// resolveHost returns the IP of a host, which may be an IP address or a host name listed in hosts,
+// or nil if unknown.
+func resolveHost(host string, hosts map[string]net.IP) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	return hosts[strings.ToLower(strings.TrimSuffix(host, "."))]
+}
+
+// multiaddrHost returns the host of a multiaddr such as /dns/example.com/tcp/8080/http or /ip4/192.0.2.1/udp/8084.
+func multiaddrHost(addr string) string {
+	parts := strings.Split(strings.TrimPrefix(addr, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	switch parts[0] {
+	case "ip4", "ip6", "dns", "dns4", "dns6":
+		return parts[1]
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,51 @@
+package chains
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInfrastructureGroupings(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Setenv("GEOIP_ASN_DB", write("asn.csv", "network,asn,organization\n192.0.2.0/24,64500,Example Hosting\n198.51.100.0/24,64501,Other Hosting\n"))
+	t.Setenv("GEOIP_COUNTRY_DB", write("country.csv", "network,country_code\n192.0.2.0/24,de\n198.51.100.0/25,US\n"))
+	t.Setenv("GEOIP_HOSTS_FILE", write("hosts", "# precomputed\n198.51.100.7 node.example.com other.example.com.\n"))
+
+	groupings := infrastructureGroupings(map[string]string{
+		"literal":    "192.0.2.10",
+		"listed":     "Node.Example.com",
+		"alias":      "other.example.com",
+		"unresolved": "unknown.example.com",
+	})
+
+	tests := []struct {
+		grouping string
+		want     map[string]string
+	}{
+		{grouping: "asn", want: map[string]string{"literal": "AS64500", "listed": "AS64501", "alias": "AS64501"}},
+		{grouping: "hosting_provider", want: map[string]string{"literal": "Example Hosting", "listed": "Other Hosting", "alias": "Other Hosting"}},
+		{grouping: "country", want: map[string]string{"literal": "DE", "listed": "US", "alias": "US"}},
+	}
+
+	for _, tt := range tests {
+		got := groupings[tt.grouping]
+		if len(got) != len(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.grouping, got, tt.want)
+			continue
+		}
+		for validator, value := range tt.want {
+			if got[validator] != value {
+				t.Errorf("%s of %s = %q, want %q", tt.grouping, validator, got[validator], value)
+			}
+		}
+	}
+}
@@ -2,96 +2,153 @@ package chains
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"math/big"
 	"net/http"
-	"sort"
+	"os"
+	"strings"
+	"time"
+)
 
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
+const (
+	// graphSubgraphURL is the Graph Network subgraph on Arbitrum, queried through the gateway.
+	// It is used when GRAPH_SUBGRAPH_URL is not set.
+	graphSubgraphURL = "https://gateway.thegraph.com/api/subgraphs/id/DZz4kDTdmzWLWsV373w2bSmoar3umKKH9y82SUKr5qmp"
+	// graphPageSize is the number of indexers requested per page, the maximum allowed by graph-node.
+	graphPageSize = 1000
 )
 
+// graphIndexersQuery pages through the indexers with stake, ordered by id, starting after $lastID.
+const graphIndexersQuery = `query($first: Int!, $lastID: String!) {
+  graphNetwork(id: "1") { delegationRatio }
+  indexers(first: $first, orderBy: id, orderDirection: asc, where: {id_gt: $lastID, stakedTokens_gt: "0"}) {
+    id stakedTokens delegatedTokens
+  }
+}`
+
 type GraphResponse struct {
 	Data struct {
+		// GraphNetwork is nil when the subgraph has no network entity.
+		GraphNetwork *struct {
+			DelegationRatio int64 `json:"delegationRatio"`
+		} `json:"graphNetwork"`
 		Indexers []struct {
-			Id           string `json:"id"`
-			StakedTokens string `json:"stakedTokens"`
+			Id              string `json:"id"`
+			StakedTokens    string `json:"stakedTokens"`
+			DelegatedTokens string `json:"delegatedTokens"`
 		} `json:"indexers"`
 	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
 }
 
-type GraphErrorResponse struct {
-	Id      int    `json:"id"`
-	Jsonrpc string `json:"jsonrpc"`
-	Error   string `json:"error"`
-}
+// Graph returns the stake of the indexers of The Graph, their own stake plus the stake delegated to them.
+// Delegation beyond the delegation ratio times the indexer's own stake cannot be used for indexing, so it is
+// not counted. The network subgraph is read from GRAPH_SUBGRAPH_URL, with GRAPH_API_KEY as the gateway API key.
+func Graph() (Distribution, error) {
+	url := os.Getenv("GRAPH_SUBGRAPH_URL")
+	if url == "" {
+		url = graphSubgraphURL
+	}
+	apiKey := os.Getenv("GRAPH_API_KEY")
+
+	var (
+		votingPowers []Validator
+		lastID       string
+	)
+	for {
+		response, err := fetchGraphIndexersPage(url, apiKey, lastID)
+		if err != nil {
+			return Distribution{}, err
+		}
+
+		// A missing ratio would cap every indexer's delegation to zero.
+		if response.Data.GraphNetwork == nil {
+			return Distribution{}, errors.New("graph network missing from the subgraph")
+		}
+		if response.Data.GraphNetwork.DelegationRatio <= 0 {
+			return Distribution{}, fmt.Errorf("invalid delegation ratio %d", response.Data.GraphNetwork.DelegationRatio)
+		}
+		ratio := big.NewInt(response.Data.GraphNetwork.DelegationRatio)
+		for _, ele := range response.Data.Indexers {
+			staked, ok := new(big.Int).SetString(ele.StakedTokens, 10)
+			if !ok {
+				return Distribution{}, fmt.Errorf("failed to parse staked tokens %q of indexer %s", ele.StakedTokens, ele.Id)
+			}
+			delegated, ok := new(big.Int).SetString(ele.DelegatedTokens, 10)
+			if !ok {
+				return Distribution{}, fmt.Errorf("failed to parse delegated tokens %q of indexer %s", ele.DelegatedTokens, ele.Id)
+			}
+
+			if capacity := new(big.Int).Mul(staked, ratio); delegated.Cmp(capacity) > 0 {
+				delegated = capacity
+			}
+
+			votingPowers = append(votingPowers, Validator{Address: ele.Id, VotingPower: new(big.Int).Add(staked, delegated)})
+		}
+
+		if len(response.Data.Indexers) < graphPageSize {
+			break
+		}
+		lastID = response.Data.Indexers[len(response.Data.Indexers)-1].Id
+	}
+
+	log.Printf("Fetched %d indexers of The Graph", len(votingPowers))
 
-func Graph() (int, error) {
-	votingPowers := make([]big.Int, 0, 1000)
+	return Distribution{Validators: votingPowers}, nil
+}
 
-	// Sometimes, the gateway URL doesn't work idk why
-	url := fmt.Sprintf("https://gateway.thegraph.com/network")
-	// url := fmt.Sprintf("https://api.thegraph.com/subgraphs/name/graphprotocol/graph-network-mainnet")
-	jsonReqData := []byte(`{"query":"{ indexers (first: 1000) { id stakedTokens } }","variables":{}}`)
+func fetchGraphIndexersPage(url, apiKey, lastID string) (GraphResponse, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFunc()
 
-	// Create a new request using http
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonReqData))
+	reqData, err := json.Marshal(map[string]interface{}{
+		"query":     graphIndexersQuery,
+		"variables": map[string]interface{}{"first": graphPageSize, "lastID": lastID},
+	})
 	if err != nil {
-		return 0, err
+		return GraphResponse{}, err
 	}
-	req.Header.Add("Content-Type", "application/json")
 
-	// Send req using http Client
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqData))
+	if err != nil {
+		log.Println(err)
+		return GraphResponse{}, errors.New("create post request for graph indexers")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
 
+	resp, err := new(http.Client).Do(req)
 	if err != nil {
-		errBody, _ := ioutil.ReadAll(resp.Body)
-		var errResp GraphErrorResponse
-		json.Unmarshal(errBody, &errResp)
-		log.Println(errResp.Error)
-		return 0, err
+		log.Println(err)
+		return GraphResponse{}, errors.New("post request unsuccessful for graph indexers")
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return GraphResponse{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return GraphResponse{}, fmt.Errorf("graph indexers request failed: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
 	var response GraphResponse
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return 0, err
+		return GraphResponse{}, err
 	}
-
-	// loop through the validators voting powers
-	for _, ele := range response.Data.Indexers {
-		n, ok := new(big.Int).SetString(ele.StakedTokens, 10)
-		if !ok {
-			log.Fatalln("Couldn't parse string", ele.StakedTokens)
-		} else {
-			votingPowers = append(votingPowers, *n)
-		}
+	if len(response.Errors) > 0 {
+		return GraphResponse{}, fmt.Errorf("graph indexers query failed: %s", response.Errors[0].Message)
 	}
 
-	// need to sort the powers in descending order since they are in random order
-	sort.Slice(votingPowers, func(i, j int) bool {
-		res := (&votingPowers[i]).Cmp(&votingPowers[j])
-		if res == 1 {
-			return true
-		}
-		return false
-	})
-
-	totalVotingPower := utils.CalculateTotalVotingPowerBigNums(votingPowers)
-	fmt.Println("Total voting power:", totalVotingPower)
-
-	// now we're ready to calculate the nakomoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for graph protocol is", nakamotoCoefficient)
-
-	return nakamotoCoefficient, nil
+	return response, nil
 }
@@ -0,0 +1,55 @@
+package chains
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	const indexers = `"indexers": [
+		{"id": "0x01", "stakedTokens": "100", "delegatedTokens": "500"},
+		{"id": "0x02", "stakedTokens": "100", "delegatedTokens": "2000"}
+	]`
+
+	tests := []struct {
+		name    string
+		network string
+		want    map[string]int64
+		wantErr bool
+	}{
+		{name: "capped delegation", network: `"graphNetwork": {"delegationRatio": 16},`, want: map[string]int64{"0x01": 600, "0x02": 1700}},
+		{name: "missing network", network: `"graphNetwork": null,`, wantErr: true},
+		{name: "zero ratio", network: `"graphNetwork": {"delegationRatio": 0},`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"data": {%s %s}}`, tt.network, indexers)
+			}))
+			defer server.Close()
+			t.Setenv("GRAPH_SUBGRAPH_URL", server.URL)
+
+			dist, err := Graph()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]int64)
+			for _, v := range dist.Validators {
+				got[v.Address] = v.VotingPower.Int64()
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("stakes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
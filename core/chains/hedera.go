@@ -5,35 +5,32 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
-	"sort"
-
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
 )
 
 const TinyToHbar = 100_000_000 // Tinybar to Hbar.
 
 type Node []struct {
-	Description     string `json:"description"`
-	Node_Account    string `json:"node_account_id"`
-	Stake           int64  `json:"stake"`
+	Description  string `json:"description"`
+	Node_Account string `json:"node_account_id"`
+	Stake        int64  `json:"stake"`
 }
 
-type Link struct{
-	Next	string `json:"next"`
+type Link struct {
+	Next string `json:"next"`
 }
 
 type HederaResponse struct {
-	Nodes	Node
-	Links	Link
+	Nodes Node
+	Links Link
 }
 
-func Hedera() (int, error){
+func Hedera() (Distribution, error) {
 	// Set base url for requests.
 	var baseURL = "https://mainnet-public.mirrornode.hedera.com"
 	var query = "/api/v1/network/nodes"
 
 	// Declare variable for tracking votes for each node.
-	var votingPowers []big.Int
+	var votingPowers []Validator
 
 	// Declare variables for tracking pagination.
 	var page = ""
@@ -44,7 +41,7 @@ func Hedera() (int, error){
 		resp, err := http.Get(fmt.Sprintf("%s%s", baseURL, query))
 		if err != nil {
 			fmt.Println(err)
-			return 0, err
+			return Distribution{}, err
 		}
 		defer resp.Body.Close()
 
@@ -53,20 +50,23 @@ func Hedera() (int, error){
 		err = json.NewDecoder(resp.Body).Decode(&response)
 		if err != nil {
 			fmt.Println(err)
-			return 0, err
+			return Distribution{}, err
 		}
-		
+
 		// Append node votes to array (from response).
 		for _, node := range response.Nodes {
-			votingPowers = append(votingPowers, *big.NewInt(node.Stake/TinyToHbar)) // Convert tinybar to hbar.
+			votingPowers = append(votingPowers, Validator{
+				Address:     node.Node_Account,
+				VotingPower: big.NewInt(node.Stake / TinyToHbar), // Convert tinybar to hbar.
+			})
 		}
 
 		// Assign next page of results to parse (null if empty, otherwise string).
 		page = response.Links.Next
 
 		// Break loop where there is no more data.
-		if page == "" || page == "null" { 
-			break 
+		if page == "" || page == "null" {
+			break
 		}
 
 		// Assign new query to api call and reset page variable.
@@ -74,18 +74,5 @@ func Hedera() (int, error){
 		page = ""
 	}
 
-	// Sort the node votes in descending order.
-	sort.Slice(votingPowers, func(i, j int) bool {
-		return (&votingPowers[i]).Cmp(&votingPowers[j]) == 1
-	})	
-
-	// Calculate the total voting power.
-	totalVotingPower := utils.CalculateTotalVotingPowerBigNums(votingPowers)
-	fmt.Println("Total voting power for Hedera is:", new(big.Float).SetInt(totalVotingPower))
-
-	// Now we're ready to calculate the nakomoto coefficient.
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for Hedera is", nakamotoCoefficient)
-
-	return nakamotoCoefficient, nil	
-}
\ No newline at end of file
+	return Distribution{Validators: votingPowers}, nil
+}
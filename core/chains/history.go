@@ -0,0 +1,177 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoricalValue is the Nakamoto coefficient of a chain at a past block height.
+type HistoricalValue struct {
+	Token           Token     `json:"chain_token"`
+	Height          int64     `json:"height"`
+	Time            time.Time `json:"time"`
+	NCVal           int       `json:"naka_co_val"`
+	EntityNCVal     int       `json:"naka_co_entity_val,omitempty"`
+	ControllerNCVal int       `json:"naka_co_controller_val,omitempty"`
+}
+
+type cosmosBlockData struct {
+	Block struct {
+		Header struct {
+			Height string    `json:"height"`
+			Time   time.Time `json:"time"`
+		} `json:"header"`
+	} `json:"block"`
+}
+
+// CosmosValueAt computes the coefficients of a Cosmos SDK chain at the given height from the REST API
+// at restURL, which must be served by an archive node that still has the state of that height.
+func CosmosValueAt(token Token, restURL string, height int64) (HistoricalValue, error) {
+	restURL = strings.TrimSuffix(restURL, "/")
+
+	block, err := fetchCosmosBlock(restURL + fmt.Sprintf("/cosmos/base/tendermint/v1beta1/blocks/%d", height))
+	if err != nil {
+		return HistoricalValue{}, fmt.Errorf("failed to fetch block %d: %w", height, err)
+	}
+
+	validatorURL := restURL + "/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED"
+	poolURL := restURL + "/cosmos/staking/v1beta1/pool"
+	dist, err := FetchCosmosSDKNakaCoeffAtHeight(token, validatorURL, poolURL, height)
+	if err != nil {
+		return HistoricalValue{}, err
+	}
+
+	val, _, err := dist.Calculate()
+	if err != nil {
+		return HistoricalValue{}, err
+	}
+
+	entityVal, err := entityValue(token, dist)
+	if err != nil {
+		log.Printf("Failed to calculate entity-level Nakamoto coefficient for %s at %d: %v", token, height, err)
+	}
+
+	var controllerVal int
+	if len(dist.Delegations) > 0 {
+		controllerVal, _, err = dist.GroupByController().Calculate()
+		if err != nil {
+			log.Printf("Failed to calculate controller-level Nakamoto coefficient for %s at %d: %v", token, height, err)
+		}
+	}
+
+	return HistoricalValue{
+		Token:           token,
+		Height:          height,
+		Time:            block.Block.Header.Time,
+		NCVal:           val,
+		EntityNCVal:     entityVal,
+		ControllerNCVal: controllerVal,
+	}, nil
+}
+
+// errCosmosBlockUnavailable is returned for a height the node no longer or never had, such as a pruned one.
+var errCosmosBlockUnavailable = errors.New("block not available")
+
+// CosmosHeightAt returns the first block height at or after t, found by binary search over the blocks
+// of the REST API at restURL. Heights the node reports as pruned or not found are treated as being
+// before t; any other failure aborts the search, since guessing would return a wrong height.
+func CosmosHeightAt(restURL string, t time.Time) (int64, error) {
+	restURL = strings.TrimSuffix(restURL, "/")
+
+	latest, err := fetchCosmosBlock(restURL + "/cosmos/base/tendermint/v1beta1/blocks/latest")
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch latest block: %w", err)
+	}
+	if latest.Block.Header.Time.Before(t) {
+		return 0, fmt.Errorf("%s is after the latest block", t.Format(time.RFC3339))
+	}
+
+	hi, err := strconv.ParseInt(latest.Block.Header.Height, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse latest height %q", latest.Block.Header.Height)
+	}
+
+	lo := int64(1)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		block, err := fetchCosmosBlock(restURL + fmt.Sprintf("/cosmos/base/tendermint/v1beta1/blocks/%d", mid))
+		if err != nil && !errors.Is(err, errCosmosBlockUnavailable) {
+			return 0, fmt.Errorf("failed to fetch block %d: %w", mid, err)
+		}
+		if err != nil || block.Block.Header.Time.Before(t) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo, nil
+}
+
+func fetchCosmosBlock(url string) (cosmosBlockData, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Println(err)
+		return cosmosBlockData{}, errors.New("create get request for cosmos block")
+	}
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return cosmosBlockData{}, errors.New("get request unsuccessful for cosmos block")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cosmosBlockData{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if cosmosBlockUnavailable(resp.StatusCode, body) {
+			return cosmosBlockData{}, fmt.Errorf("%w: %s", errCosmosBlockUnavailable, strings.TrimSpace(string(body)))
+		}
+		return cosmosBlockData{}, fmt.Errorf("cosmos block request responded with %d", resp.StatusCode)
+	}
+
+	var response cosmosBlockData
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return cosmosBlockData{}, err
+	}
+
+	return response, nil
+}
+
+// cosmosBlockUnavailable reports whether a failed block request means the height is pruned or not found,
+// as opposed to a transient failure of the node. The gRPC gateway reports both with a message such as
+// "height 5 is not available, lowest height is 100" or "could not find results for height #5".
+func cosmosBlockUnavailable(status int, body []byte) bool {
+	if status == http.StatusNotFound {
+		return true
+	}
+	// Gateway and proxy errors such as 502 and 504 are never about the height.
+	if status > http.StatusInternalServerError {
+		return false
+	}
+
+	message := strings.ToLower(string(body))
+	for _, s := range []string{"lowest height is", "is not available", "could not find", "not found"} {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package chains
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCosmosHeightAt(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		pruned  int64 // heights up to pruned are answered with pruned
+		failing int64 // height answered with a 503, if any
+		pruneBy string
+		want    int64
+		wantErr bool
+	}{
+		{name: "full history", want: 61},
+		{name: "pruned below target", pruned: 40, pruneBy: "height 1 is not available, lowest height is 41", want: 61},
+		{name: "not found below target", pruned: 40, pruneBy: "could not find results for height #1", want: 61},
+		{name: "unavailable node", failing: 50, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Block h is produced h minutes after start, up to height 100.
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				param := strings.TrimPrefix(r.URL.Path, "/cosmos/base/tendermint/v1beta1/blocks/")
+				height := int64(100)
+				if param != "latest" {
+					height, _ = strconv.ParseInt(param, 10, 64)
+				}
+
+				switch {
+				case height <= tt.pruned:
+					w.WriteHeader(http.StatusInternalServerError)
+					fmt.Fprintf(w, `{"code":2,"message":%q}`, tt.pruneBy)
+				case height == tt.failing:
+					w.WriteHeader(http.StatusServiceUnavailable)
+				default:
+					fmt.Fprintf(w, `{"block":{"header":{"height":"%d","time":%q}}}`,
+						height, start.Add(time.Duration(height)*time.Minute).Format(time.RFC3339))
+				}
+			}))
+			defer server.Close()
+
+			got, err := CosmosHeightAt(server.URL, start.Add(60*time.Minute+time.Second))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got height %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("height = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
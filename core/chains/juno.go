@@ -1,8 +1,8 @@
 package chains
 
-func Juno() (int, error) {
-	validatorsURL := "https://api.juno.basementnodes.ca/cosmos/staking/v1beta1/validators?page.offset=1&pagination.limit=100&status=BOND_STATUS_BONDED"
+func Juno() (Distribution, error) {
+	validatorsURL := "https://api.juno.basementnodes.ca/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED"
 	stakingPoolURL := "https://api.juno.basementnodes.ca/cosmos/staking/v1beta1/pool"
 
-	return FetchCosmosSDKNakaCoeff("juno", validatorsURL, stakingPoolURL)
+	return FetchCosmosSDKNakaCoeff(JUNO, validatorsURL, stakingPoolURL)
 }
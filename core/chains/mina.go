@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"net/http"
-	"sort"
 	"time"
 )
 
@@ -30,15 +30,8 @@ type MinaErrorResponse struct {
 	Error   string `json:"error"`
 }
 
-func reverse(numbers []float64) {
-	for i, j := 0, len(numbers)-1; i < j; i, j = i+1, j-1 {
-		numbers[i], numbers[j] = numbers[j], numbers[i]
-	}
-}
-
-func Mina() (int, error) {
-	var votingPowers []float64
-	var totalStake float64
+func Mina() (Distribution, error) {
+	var votingPowers []Validator
 	pageNo, entriesPerPage := 0, 50
 	url := ""
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
@@ -51,25 +44,25 @@ func Mina() (int, error) {
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err != nil {
 			log.Println(err)
-			return 0, errors.New("create get request for mina")
+			return Distribution{}, errors.New("create get request for mina")
 		}
 
 		resp, err := new(http.Client).Do(req)
 		if err != nil {
 			log.Println(err)
-			return 0, errors.New("get request unsuccessful")
+			return Distribution{}, errors.New("get request unsuccessful")
 		}
 		defer resp.Body.Close()
 
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return 0, err
+			return Distribution{}, err
 		}
 
 		var response MinaResponse
 		err = json.Unmarshal(body, &response)
 		if err != nil {
-			return 0, err
+			return Distribution{}, err
 		}
 
 		// Break if no content or all pages have been fetched
@@ -78,42 +71,18 @@ func Mina() (int, error) {
 		}
 
 		// loop through the validators voting powers
+		// The explorer only reports stake percentages, which are scaled to integers.
 		for _, ele := range response.Content {
-			votingPowers = append(votingPowers, ele.StakePercent)
-			// Accumulate total stake of all validators
-			totalStake += ele.StakePercent
+			votingPowers = append(votingPowers, Validator{
+				Address:     ele.Pk,
+				VotingPower: big.NewInt(int64(ele.StakePercent * 1e6)),
+			})
 		}
 
 		// increment counters
 		pageNo += 1
 	}
 
-	// Sort voting powers in descending order
-	sort.Slice(votingPowers, func(i, j int) bool {
-		return votingPowers[i] > votingPowers[j]
-	})
-
-	// now we're ready to calculate the Nakamoto coefficient
-	nakamotoCoefficient := calcNakamotoCoefficientForMina(votingPowers, totalStake)
-	fmt.Println("The Nakamoto coefficient for Mina is", nakamotoCoefficient)
-
-	return nakamotoCoefficient, nil
-}
-
-func calcNakamotoCoefficientForMina(votingPowers []float64, totalStake float64) int {
-	// Calculate 50% threshold dynamically based on total stake
-	var cumulativePercent float64 = 0.00
-	thresholdPercent := totalStake * 0.50
-	nakamotoCoefficient := 0
-
-	for _, vpp := range votingPowers {
-		// since this is the actual voting percentage, no need to multiply with 100
-		cumulativePercent += vpp
-		nakamotoCoefficient += 1
-		// Check if cumulative voting power exceeds 50% of total stake
-		if cumulativePercent >= thresholdPercent {
-			break
-		}
-	}
-	return nakamotoCoefficient
+	// Mina requires a majority of the stake to be controlled.
+	return Distribution{Validators: votingPowers, ThresholdPercent: 50}, nil
 }
@@ -0,0 +1,292 @@
+package chains
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// mmdbMetadataMarker precedes the metadata at the end of a MaxMind DB file.
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// mmdbMaxDepth bounds the nesting of maps, arrays and pointers, so that a pointer back into an enclosing
+// map cannot recurse forever.
+const mmdbMaxDepth = 32
+
+// mmdbReader looks up IP addresses in a MaxMind DB file (https://maxmind.github.io/MaxMind-DB/),
+// such as the GeoLite2 ASN and Country databases. The whole file is read into memory.
+type mmdbReader struct {
+	buf        []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	// dataStart is the offset of the data section, right after the search tree and its 16 byte separator.
+	dataStart uint
+}
+
+func openMMDB(path string) (*mmdbReader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	i := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("%s is not a MaxMind DB", path)
+	}
+	metaStart := uint(i + len(mmdbMetadataMarker))
+
+	r := &mmdbReader{buf: buf}
+	value, _, err := r.decode(metaStart, metaStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode metadata of %s: %w", path, err)
+	}
+	meta, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid metadata in %s", path)
+	}
+
+	for key, dst := range map[string]*uint{"node_count": &r.nodeCount, "record_size": &r.recordSize, "ip_version": &r.ipVersion} {
+		n, ok := meta[key].(uint64)
+		if !ok {
+			return nil, fmt.Errorf("missing %s in metadata of %s", key, path)
+		}
+		*dst = uint(n)
+	}
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d in %s", r.recordSize, path)
+	}
+
+	r.dataStart = r.nodeCount*r.recordSize/4 + 16
+	if r.dataStart > uint(len(buf)) {
+		return nil, fmt.Errorf("search tree of %s exceeds the file", path)
+	}
+
+	return r, nil
+}
+
+// lookup returns the record of the network containing ip, or nil if there is none.
+func (r *mmdbReader) lookup(ip net.IP) (map[string]interface{}, error) {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		// IPv4 networks are stored under ::/96 in IPv6 databases.
+		if r.ipVersion == 6 {
+			ip = append(make(net.IP, 12), ip4...)
+		}
+	} else if r.ipVersion == 4 {
+		return nil, nil
+	}
+
+	node := uint(0)
+	for i := 0; i < len(ip)*8 && node < r.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-uint(i%8))) & 1
+
+		var err error
+		node, err = r.record(node, bit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case node == r.nodeCount:
+		return nil, nil
+	case node < r.nodeCount:
+		return nil, errors.New("invalid search tree")
+	}
+
+	value, _, err := r.decode(r.dataStart, r.dataStart+node-r.nodeCount-16)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("record is not a map")
+	}
+
+	return record, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a search tree node.
+func (r *mmdbReader) record(node, bit uint) (uint, error) {
+	nodeLen := r.recordSize / 4
+	off := node * nodeLen
+	if off+nodeLen > uint(len(r.buf)) {
+		return 0, errors.New("node out of range")
+	}
+	b := r.buf[off : off+nodeLen]
+
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6]), nil
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:])), nil
+	}
+}
+
+// decode decodes the data field at off, where pointers are relative to base.
+// It returns the value and the offset following the field.
+func (r *mmdbReader) decode(base, off uint) (interface{}, uint, error) {
+	return r.decodeAt(base, off, 0)
+}
+
+// decodeAt decodes the data field at off, nested depth levels into the field decode was called for.
+func (r *mmdbReader) decodeAt(base, off uint, depth int) (interface{}, uint, error) {
+	if depth > mmdbMaxDepth {
+		return nil, 0, errors.New("data nested too deeply")
+	}
+
+	next := func(n uint) ([]byte, error) {
+		if off+n > uint(len(r.buf)) {
+			return nil, errors.New("data field out of range")
+		}
+		b := r.buf[off : off+n]
+		off += n
+		return b, nil
+	}
+
+	b, err := next(1)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctrl := b[0]
+	typ := uint(ctrl >> 5)
+
+	if typ == 1 {
+		ss, vvv := uint(ctrl>>3)&3, uint(ctrl&7)
+		b, err := next(ss + 1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var pointer uint
+		switch ss {
+		case 0:
+			pointer = vvv<<8 | uint(b[0])
+		case 1:
+			pointer = (vvv<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+		case 2:
+			pointer = (vvv<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+		default:
+			pointer = uint(binary.BigEndian.Uint32(b))
+		}
+
+		// Pointers never point to pointers, so at most one is followed.
+		target := base + pointer
+		if target >= uint(len(r.buf)) {
+			return nil, 0, errors.New("pointer out of range")
+		}
+		if r.buf[target]>>5 == 1 {
+			return nil, 0, errors.New("pointer to a pointer")
+		}
+		value, _, err := r.decodeAt(base, target, depth+1)
+		return value, off, err
+	}
+
+	if typ == 0 {
+		b, err := next(1)
+		if err != nil {
+			return nil, 0, err
+		}
+		typ = 7 + uint(b[0])
+	}
+
+	size := uint(ctrl & 0x1f)
+	switch size {
+	case 29, 30, 31:
+		b, err := next(size - 28)
+		if err != nil {
+			return nil, 0, err
+		}
+		n := uint(0)
+		for _, c := range b {
+			n = n<<8 | uint(c)
+		}
+		size = map[uint]uint{29: 29, 30: 285, 31: 65821}[size] + n
+	}
+
+	switch typ {
+	case 7:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, keyEnd, err := r.decodeAt(base, off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			s, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+
+			value, valueEnd, err := r.decodeAt(base, keyEnd, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[s] = value
+			off = valueEnd
+		}
+		return m, off, nil
+	case 11:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, end, err := r.decodeAt(base, off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+			off = end
+		}
+		return a, off, nil
+	case 14:
+		return size != 0, off, nil
+	}
+
+	b, err = next(size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch typ {
+	case 2:
+		return string(b), off, nil
+	case 3:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double size")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), off, nil
+	case 4:
+		return b, off, nil
+	case 5, 6, 9:
+		n := uint64(0)
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, off, nil
+	case 8:
+		n := int32(0)
+		for _, c := range b {
+			n = n<<8 | int32(c)
+		}
+		return n, off, nil
+	case 10:
+		return new(big.Int).SetBytes(b), off, nil
+	case 15:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float size")
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), off, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type %d", typ)
+	}
+}
@@ -0,0 +1,270 @@
+package chains
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// mmdbField encodes the control byte and extended size of a data field of the given type and size.
+func mmdbField(typ, size int) []byte {
+	var ext []byte
+	switch {
+	case size >= 65821:
+		ext = []byte{byte((size - 65821) >> 16), byte((size - 65821) >> 8), byte(size - 65821)}
+		size = 31
+	case size >= 285:
+		ext = []byte{byte((size - 285) >> 8), byte(size - 285)}
+		size = 30
+	case size >= 29:
+		ext = []byte{byte(size - 29)}
+		size = 29
+	}
+
+	if typ > 7 {
+		return append([]byte{byte(size), byte(typ - 7)}, ext...)
+	}
+	return append([]byte{byte(typ<<5 | size)}, ext...)
+}
+
+func mmdbString(s string) []byte {
+	return append(mmdbField(2, len(s)), s...)
+}
+
+func mmdbUint32(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return append(mmdbField(6, 4), b...)
+}
+
+// mmdbMap encodes a map whose values are already encoded, with its keys in sorted order.
+func mmdbMap(entries map[string][]byte) []byte {
+	var keys []string
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	b := mmdbField(7, len(entries))
+	for _, key := range keys {
+		b = append(b, mmdbString(key)...)
+		b = append(b, entries[key]...)
+	}
+	return b
+}
+
+// mmdbPointer encodes a pointer to offset p of the data section in its shortest form.
+func mmdbPointer(p int) []byte {
+	switch {
+	case p < 2048:
+		return []byte{byte(1<<5 | p>>8), byte(p)}
+	case p < 526336:
+		p -= 2048
+		return []byte{byte(1<<5 | 1<<3 | p>>16), byte(p >> 8), byte(p)}
+	case p < 134744064:
+		p -= 526336
+		return []byte{byte(1<<5 | 2<<3 | p>>24), byte(p >> 16), byte(p >> 8), byte(p)}
+	default:
+		b := []byte{1<<5 | 3<<3, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(b[1:], uint32(p))
+		return b
+	}
+}
+
+// mmdbNode encodes a search tree node with the given left and right records.
+func mmdbNode(recordSize int, left, right uint32) []byte {
+	switch recordSize {
+	case 24:
+		return []byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)}
+	case 28:
+		return []byte{byte(left >> 16), byte(left >> 8), byte(left), byte(left>>24<<4 | right>>24&0x0f),
+			byte(right >> 16), byte(right >> 8), byte(right)}
+	default:
+		return binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, left), right)
+	}
+}
+
+// mmdbNetwork is a network of a test database and the offset of its record in the data section.
+type mmdbNetwork struct {
+	ip     net.IP
+	bits   int
+	record int
+}
+
+// writeMMDB writes a MaxMind DB with the given networks and data section, and returns its path.
+func writeMMDB(t *testing.T, recordSize, ipVersion int, networks []mmdbNetwork, data []byte) string {
+	t.Helper()
+
+	// Records are node indexes, or -1 for no data, or -2-offset for a data record, until the node count is known.
+	nodes := [][2]int{{-1, -1}}
+	for _, network := range networks {
+		node := 0
+		for i := 0; i < network.bits; i++ {
+			bit := int(network.ip[i/8]>>(7-uint(i%8))) & 1
+			if i == network.bits-1 {
+				nodes[node][bit] = -2 - network.record
+				break
+			}
+			if nodes[node][bit] < 0 {
+				nodes = append(nodes, [2]int{-1, -1})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	count := len(nodes)
+	resolve := func(rec int) uint32 {
+		switch {
+		case rec == -1:
+			return uint32(count)
+		case rec < -1:
+			return uint32(count + 16 + (-2 - rec))
+		default:
+			return uint32(rec)
+		}
+	}
+
+	var buf []byte
+	for _, node := range nodes {
+		buf = append(buf, mmdbNode(recordSize, resolve(node[0]), resolve(node[1]))...)
+	}
+	buf = append(buf, make([]byte, 16)...)
+	buf = append(buf, data...)
+	buf = append(buf, mmdbMetadataMarker...)
+	buf = append(buf, mmdbMap(map[string][]byte{
+		"node_count":  mmdbUint32(uint32(count)),
+		"record_size": mmdbUint32(uint32(recordSize)),
+		"ip_version":  mmdbUint32(uint32(ipVersion)),
+	})...)
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMMDBLookup(t *testing.T) {
+	var (
+		long   = strings.Repeat("l", 40)    // extended size 29
+		longer = strings.Repeat("m", 300)   // extended size 30
+		huge   = strings.Repeat("h", 70000) // extended size 31
+		data   []byte
+	)
+	add := func(b []byte) int {
+		off := len(data)
+		data = append(data, b...)
+		return off
+	}
+
+	shared := add(mmdbString("Shared Hosting"))
+	hugeOff := add(mmdbString(huge))
+	far := add(mmdbString("Far")) // only reachable with a pointer longer than 11 bits
+	first := add(mmdbMap(map[string][]byte{
+		"organization": mmdbPointer(shared),
+		"city":         mmdbPointer(far),
+		"note":         mmdbString(long),
+		"asn":          mmdbUint32(64500),
+	}))
+	second := add(mmdbMap(map[string][]byte{
+		"organization": mmdbString(longer),
+		"note":         mmdbPointer(hugeOff),
+	}))
+
+	for _, recordSize := range []int{24, 28, 32} {
+		for _, ipVersion := range []int{4, 6} {
+			networks := []mmdbNetwork{{ip: net.IPv4(192, 0, 2, 0).To4(), bits: 24, record: first}}
+			if ipVersion == 6 {
+				// IPv4 networks are stored under ::/96.
+				networks = []mmdbNetwork{
+					{ip: append(make(net.IP, 12), 192, 0, 2, 0), bits: 96 + 24, record: first},
+					{ip: net.ParseIP("2001:db8::"), bits: 32, record: second},
+				}
+			}
+
+			db, err := openMMDB(writeMMDB(t, recordSize, ipVersion, networks, data))
+			if err != nil {
+				t.Fatalf("record size %d, IPv%d: %v", recordSize, ipVersion, err)
+			}
+
+			record, err := db.lookup(net.ParseIP("192.0.2.10"))
+			if err != nil {
+				t.Fatalf("record size %d, IPv%d: %v", recordSize, ipVersion, err)
+			}
+			if record["organization"] != "Shared Hosting" || record["city"] != "Far" || record["note"] != long || record["asn"] != uint64(64500) {
+				t.Errorf("record size %d, IPv%d: got record %v", recordSize, ipVersion, record)
+			}
+
+			for _, ip := range []string{"192.0.3.10", "198.51.100.1", "2001:db9::1"} {
+				record, err := db.lookup(net.ParseIP(ip))
+				if err != nil || record != nil {
+					t.Errorf("record size %d, IPv%d: lookup of %s = %v, %v, want no record", recordSize, ipVersion, ip, record, err)
+				}
+			}
+
+			if ipVersion == 4 {
+				continue
+			}
+			record, err = db.lookup(net.ParseIP("2001:db8::1"))
+			if err != nil {
+				t.Fatalf("record size %d, IPv%d: %v", recordSize, ipVersion, err)
+			}
+			if record["organization"] != longer || record["note"] != huge {
+				t.Errorf("record size %d, IPv%d: got a record with %d and %d byte values", recordSize, ipVersion,
+					len(record["organization"].(string)), len(record["note"].(string)))
+			}
+		}
+	}
+}
+
+func TestMMDBRecord(t *testing.T) {
+	tests := []struct {
+		recordSize  int
+		left, right uint32
+	}{
+		{recordSize: 24, left: 0xabcdef, right: 0x123456},
+		{recordSize: 28, left: 0xabcdef1, right: 0x1234567},
+		{recordSize: 32, left: 0xabcdef12, right: 0x12345678},
+	}
+
+	for _, tt := range tests {
+		// The node of interest is the second one.
+		r := &mmdbReader{buf: append(mmdbNode(tt.recordSize, 0, 0), mmdbNode(tt.recordSize, tt.left, tt.right)...), recordSize: uint(tt.recordSize)}
+		for bit, want := range []uint32{tt.left, tt.right} {
+			got, err := r.record(1, uint(bit))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != uint(want) {
+				t.Errorf("record size %d: record %d = %#x, want %#x", tt.recordSize, bit, got, want)
+			}
+		}
+	}
+}
+
+func TestMMDBInvalidPointers(t *testing.T) {
+	// A pointer to a pointer.
+	var data []byte
+	data = append(data, mmdbString("x")...)
+	inner := len(data)
+	data = append(data, mmdbPointer(0)...)
+	outer := len(data)
+	data = append(data, mmdbMap(map[string][]byte{"k": mmdbPointer(inner)})...)
+
+	r := &mmdbReader{buf: data}
+	if _, _, err := r.decode(0, uint(outer)); err == nil {
+		t.Error("expected an error for a pointer to a pointer")
+	}
+
+	// A map containing a pointer to itself.
+	loop := mmdbMap(map[string][]byte{"k": mmdbPointer(0)})
+	r = &mmdbReader{buf: loop}
+	if _, _, err := r.decode(0, 0); err == nil {
+		t.Error("expected an error for a pointer loop")
+	}
+}
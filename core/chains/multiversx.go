@@ -5,10 +5,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"net/http"
-	"sort"
-
-	"github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
 )
 
 const totalValidatorsUrl = "https://api.multiversx.com/stake"
@@ -19,43 +17,43 @@ type MultiversXTotalValidatorsResponse struct {
 }
 
 type MultiversXIdentitiesResponse []struct {
+	Identity      string `json:"identity"`
 	Locked        string `json:"locked"`
 	NumValidators int64  `json:"validators"`
 }
 
-func MultiversX() (int, error) {
-	numValidatorsPerIdentity := make([]int64, 0)
+func MultiversX() (Distribution, error) {
+	numValidatorsPerIdentity := make([]Validator, 0)
 
 	totalNumberOfValidators, err := getTotalValidatorsNumber()
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	identities, err := getIdentities()
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	for _, identity := range identities {
 		if identity.Locked == "0" {
 			continue
 		}
-		numValidatorsPerIdentity = append(numValidatorsPerIdentity, identity.NumValidators)
+		numValidatorsPerIdentity = append(numValidatorsPerIdentity, Validator{
+			Address:     identity.Identity,
+			VotingPower: big.NewInt(identity.NumValidators),
+		})
 	}
 
-	sort.Slice(numValidatorsPerIdentity, func(i, j int) bool {
-		return numValidatorsPerIdentity[i] > numValidatorsPerIdentity[j]
-	})
-
 	fmt.Println("Total voting power:", totalNumberOfValidators)
 
 	// there is a fixed number of validator seats in MultiversX - currently 3200
 	// the Nakamoto coefficient can be computed by counting the identities (node operators)
 	// that control more than 33% of the total number of validators
-	nakamotoCoefficient := utils.CalcNakamotoCoefficient(totalNumberOfValidators, numValidatorsPerIdentity)
-	fmt.Println("The Nakamoto coefficient for MultiversX is", nakamotoCoefficient)
-
-	return nakamotoCoefficient, nil
+	return Distribution{
+		Validators:       numValidatorsPerIdentity,
+		TotalVotingPower: big.NewInt(totalNumberOfValidators),
+	}, nil
 }
 
 func getTotalValidatorsNumber() (int64, error) {
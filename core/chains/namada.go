@@ -9,102 +9,94 @@ import (
 	"log"
 	"math/big"
 	"net/http"
-	"sort"
 	"time"
+)
 
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
+const (
+	// namadaIndexerURL is the API of a Namada indexer, the default source of the validators.
+	namadaIndexerURL = "https://api-namada-mainnet-indexer.tm.p2p.org"
+	// namadaMicro is the number of the smallest units in one NAM, the unit of the indexer's voting powers.
+	namadaMicro = 1_000_000
 )
 
 type NamadaValidator struct {
-	VotingPower string `json:"voting_power"`
-}
-
-type NamadaValidatorsResponse struct {
-	Result struct {
-		Validators []NamadaValidator `json:"validators"`
-	} `json:"result"`
+	Address     string `json:"address"`
+	Name        string `json:"name"`
+	VotingPower string `json:"votingPower"`
 }
 
 type NamadaTotalVotingPowerResponse struct {
 	TotalVotingPower string `json:"totalVotingPower"`
 }
 
-func Namada() (int, error) {
-	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelFunc()
+// Namada returns the voting power of the validators in the consensus set from a Namada indexer, identified
+// by their tnam operator address. The CometBFT RPC source is used instead when COMETBFT_RPC_NAM is set.
+func Namada() (Distribution, error) {
+	var validators []NamadaValidator
+	if err := namadaGet(namadaIndexerURL+"/api/v1/pos/validator/all?state=consensus", &validators); err != nil {
+		return Distribution{}, fmt.Errorf("failed to fetch namada validators: %w", err)
+	}
 
-	// Fetch validators
-	validatorsURL := "https://namada-archive.tm.p2p.org/validators"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, validatorsURL, nil)
-	if err != nil {
-		log.Println(err)
-		return 0, errors.New("create get request for namada validators")
+	var total NamadaTotalVotingPowerResponse
+	if err := namadaGet(namadaIndexerURL+"/api/v1/pos/voting-power", &total); err != nil {
+		return Distribution{}, fmt.Errorf("failed to fetch namada total voting power: %w", err)
 	}
-	resp, err := new(http.Client).Do(req)
-	if err != nil {
-		log.Println(err)
-		return 0, errors.New("get request unsuccessful for namada validators")
+
+	var votingPowers []Validator
+	for _, v := range validators {
+		vp, err := namadaMicroUnits(v.VotingPower)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to parse voting power of %s: %w", v.Address, err)
+		}
+		votingPowers = append(votingPowers, Validator{Address: v.Address, Name: v.Name, VotingPower: vp})
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
+	if len(votingPowers) == 0 {
+		return Distribution{}, errors.New("no namada validators in the consensus set")
 	}
-	var valResp NamadaValidatorsResponse
-	err = json.Unmarshal(body, &valResp)
+
+	totalVotingPower, err := namadaMicroUnits(total.TotalVotingPower)
 	if err != nil {
-		return 0, err
+		return Distribution{}, fmt.Errorf("failed to parse total voting power: %w", err)
 	}
 
-	// Fetch total voting power
-	totalPowerURL := "https://api-namada-mainnet-indexer.tm.p2p.org/api/v1/pos/voting-power"
-	req2, err := http.NewRequestWithContext(ctx, http.MethodGet, totalPowerURL, nil)
-	if err != nil {
-		log.Println(err)
-		return 0, errors.New("create get request for namada total voting power")
+	return Distribution{Validators: votingPowers, TotalVotingPower: totalVotingPower}, nil
+}
+
+// namadaMicroUnits converts an amount of NAM, which may have decimals, to the smallest unit.
+func namadaMicroUnits(amount string) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", amount)
 	}
-	resp2, err := new(http.Client).Do(req2)
+	r.Mul(r, new(big.Rat).SetInt64(namadaMicro))
+
+	return new(big.Int).Quo(r.Num(), r.Denom()), nil
+}
+
+func namadaGet(url string, response interface{}) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		log.Println(err)
-		return 0, errors.New("get request unsuccessful for namada total voting power")
+		return errors.New("create get request for namada indexer")
 	}
-	defer resp2.Body.Close()
-	body2, err := io.ReadAll(resp2.Body)
-	if err != nil {
-		return 0, err
-	}
-	var totalResp NamadaTotalVotingPowerResponse
-	err = json.Unmarshal(body2, &totalResp)
+
+	resp, err := new(http.Client).Do(req)
 	if err != nil {
-		return 0, err
+		log.Println(err)
+		return errors.New("get request unsuccessful for namada indexer")
 	}
+	defer resp.Body.Close()
 
-	// Parse voting powers
-	var votingPowers []*big.Int
-	for _, v := range valResp.Result.Validators {
-		vp := new(big.Int)
-		_, ok := vp.SetString(v.VotingPower, 10)
-		if !ok {
-			log.Println("Error parsing validator voting power:", v.VotingPower)
-			continue
-		}
-		votingPowers = append(votingPowers, vp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
 	}
-
-	// Sort voting powers descending
-	sort.Slice(votingPowers, func(i, j int) bool { return votingPowers[i].Cmp(votingPowers[j]) > 0 })
-
-	totalVotingPower := new(big.Int)
-	_, ok := totalVotingPower.SetString(totalResp.TotalVotingPower, 10)
-	if !ok {
-		return 0, fmt.Errorf("error parsing total voting power: %s", totalResp.TotalVotingPower)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("namada indexer request failed: %d", resp.StatusCode)
 	}
-	// Multiply by 10^6 as required by Namada
-	multiplier := big.NewInt(1_000_000)
-	totalVotingPower.Mul(totalVotingPower, multiplier)
-
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigInt(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for Namada is", nakamotoCoefficient)
 
-	return nakamotoCoefficient, nil
+	return json.Unmarshal(body, response)
 }
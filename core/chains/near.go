@@ -1,94 +1,103 @@
 package chains
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
-	"io/ioutil"
 	"log"
 	"math/big"
-	"net/http"
-	"sort"
 )
 
-type NearResponse struct {
-	Jsonrpc string `json:"jsonrpc"`
-	Id      int    `json:"id"`
-	Result  struct {
-		Validators []struct {
-			AccountId string `json:"account_id"`
-			Stake     string `json:"stake"`
-		} `json:"current_validators"`
-	} `json:"result"`
+// nearRPCURL is the public RPC endpoint of Near mainnet.
+const nearRPCURL = "https://rpc.mainnet.near.org"
+
+type nearValidator struct {
+	AccountId string `json:"account_id"`
+	Stake     string `json:"stake"`
 }
 
-func Near() (int, error) {
-	votingPowers := make([]big.Int, 0, 1024)
+// NearResponse is the result of the validators method.
+type NearResponse struct {
+	Validators     []nearValidator `json:"current_validators"`
+	NextValidators []nearValidator `json:"next_validators"`
+}
 
-	url := fmt.Sprintf("https://rpc.mainnet.near.org")
-	jsonReqData := []byte(`{"jsonrpc": "2.0","method": "validators","params":[null],"id":1}`)
+// nearProtocolConfig holds the seats of each validator role from the EXPERIMENTAL_protocol_config method.
+// The seats go to the validators with the most stake.
+type nearProtocolConfig struct {
+	NumBlockProducerSeats  int `json:"num_block_producer_seats"`
+	NumChunkProducerSeats  int `json:"num_chunk_producer_seats"`
+	NumChunkValidatorSeats int `json:"num_chunk_validator_seats"`
+}
 
-	// Create a new POST request using http
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonReqData))
-	if err != nil {
-		return 0, err
+// Near returns the stake of the current Near validators. With stateless validation, the validators with the
+// most stake produce blocks and chunks while the others only validate chunks, so the coefficient of each role
+// is reported as the block_producer, chunk_producer and chunk_validator alternatives. The validators of the
+// next epoch are already known and returned as the next epoch's set.
+func Near() (Distribution, error) {
+	var response NearResponse
+	if err := jsonRPC(nearRPCURL, "validators", []interface{}{nil}, &response); err != nil {
+		return Distribution{}, fmt.Errorf("failed to fetch near validators: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send req using http Client
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if resp != nil {
-		// Need to close body when redirection occurs
-		// In redirection, response is not empty
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				log.Printf("failed to close response body")
-			}
-		}()
+
+	// The roles only refine the coefficient, so the stake distribution is returned without them.
+	var config nearProtocolConfig
+	if err := jsonRPC(nearRPCURL, "EXPERIMENTAL_protocol_config", map[string]string{"finality": "final"}, &config); err != nil {
+		log.Printf("Failed to fetch near protocol config: %v", err)
+		return nearDistribution(response, nil)
 	}
 
+	return nearDistribution(response, &config)
+}
+
+// nearDistribution returns the stake of the current validators, with the validators filling the seats of each
+// role in config as alternatives, if config is known.
+func nearDistribution(response NearResponse, config *nearProtocolConfig) (Distribution, error) {
+	votingPowers, err := nearVotingPowers(response.Validators)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+	nextEpoch, err := nearVotingPowers(response.NextValidators)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
-	var response NearResponse
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return 0, err
+	dist := Distribution{Validators: votingPowers, NextEpoch: nextEpoch}
+	if config == nil {
+		return dist, nil
 	}
 
-	// loop through the validators voting powers
-	for _, ele := range response.Result.Validators {
-		n, ok := new(big.Int).SetString(ele.Stake, 10)
-		if !ok {
-			return 0, fmt.Errorf("failed to parse string %s", ele.Stake)
+	dist.Alternatives = make(map[string]Distribution)
+	for role, seats := range map[string]int{
+		"block_producer":  config.NumBlockProducerSeats,
+		"chunk_producer":  config.NumChunkProducerSeats,
+		"chunk_validator": config.NumChunkValidatorSeats,
+	} {
+		if seats > 0 {
+			dist.Alternatives[role] = Distribution{Validators: largest(votingPowers, seats)}
 		}
-		votingPowers = append(votingPowers, *n)
 	}
 
-	// need to sort the powers in descending order since they are in random order
-	sort.Slice(votingPowers, func(i, j int) bool {
-		res := (&votingPowers[i]).Cmp(&votingPowers[j])
-		if res == 1 {
-			return true
+	return dist, nil
+}
+
+func nearVotingPowers(validators []nearValidator) ([]Validator, error) {
+	var votingPowers []Validator
+	for _, ele := range validators {
+		n, ok := new(big.Int).SetString(ele.Stake, 10)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse string %s", ele.Stake)
 		}
-		return false
-	})
+		votingPowers = append(votingPowers, Validator{Address: ele.AccountId, VotingPower: n})
+	}
 
-	totalVotingPower := utils.CalculateTotalVotingPowerBigNums(votingPowers)
-	fmt.Println("Total voting power:", totalVotingPower)
+	return votingPowers, nil
+}
 
-	// now we're ready to calculate the nakamoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for near protocol is", nakamotoCoefficient)
+// largest returns the n validators with the most voting power.
+func largest(validators []Validator, n int) []Validator {
+	sorted := Distribution{Validators: validators}.sorted()
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
 
-	return nakamotoCoefficient, nil
+	return sorted
 }
@@ -0,0 +1,75 @@
+package chains
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNearDistribution(t *testing.T) {
+	// A validators response trimmed to the fields read, in no particular order.
+	const response = `{
+		"current_validators": [
+			{"account_id": "c.poolv1.near", "stake": "1000"},
+			{"account_id": "a.poolv1.near", "stake": "5000"},
+			{"account_id": "d.poolv1.near", "stake": "1000"},
+			{"account_id": "b.poolv1.near", "stake": "3000"}
+		],
+		"next_validators": [
+			{"account_id": "a.poolv1.near", "stake": "5000"},
+			{"account_id": "e.poolv1.near", "stake": "5000"}
+		]
+	}`
+
+	var near NearResponse
+	if err := json.Unmarshal([]byte(response), &near); err != nil {
+		t.Fatal(err)
+	}
+
+	// The largest validator produces blocks and chunks, the second only chunks, and all validate chunks,
+	// even when the chunk validator seats outnumber the validators.
+	dist, err := nearDistribution(near, &nearProtocolConfig{NumBlockProducerSeats: 1, NumChunkProducerSeats: 2, NumChunkValidatorSeats: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		role string
+		want []string
+	}{
+		{role: "block_producer", want: []string{"a.poolv1.near"}},
+		{role: "chunk_producer", want: []string{"a.poolv1.near", "b.poolv1.near"}},
+		{role: "chunk_validator", want: []string{"a.poolv1.near", "b.poolv1.near", "c.poolv1.near", "d.poolv1.near"}},
+	}
+
+	if len(dist.Alternatives) != len(tests) {
+		t.Errorf("got %d roles, want %d", len(dist.Alternatives), len(tests))
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, v := range dist.Alternatives[tt.role].Validators {
+			got = append(got, v.Address)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s = %v, want %v", tt.role, got, tt.want)
+		}
+	}
+
+	if len(dist.Validators) != 4 || len(dist.NextEpoch) != 2 {
+		t.Errorf("got %d current and %d next validators, want 4 and 2", len(dist.Validators), len(dist.NextEpoch))
+	}
+	if val, _, err := dist.Alternatives["chunk_producer"].Calculate(); err != nil || val != 1 {
+		t.Errorf("chunk_producer coefficient = %d, %v, want 1", val, err)
+	}
+
+	// Without the protocol config the roles are unknown.
+	dist, err = nearDistribution(near, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dist.Validators) != 4 || dist.Alternatives != nil {
+		t.Errorf("got %d validators and roles %v without a protocol config, want 4 and none", len(dist.Validators), dist.Alternatives)
+	}
+}
@@ -1,69 +1,112 @@
 package chains
 
 import (
-	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"sort"
-	"time"
+	"math/big"
+)
 
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
+const (
+	// polygonRPCURL is a public Ethereum JSON-RPC endpoint used when EVM_RPC_MATIC is not set,
+	// since the Polygon PoS staking contracts live on Ethereum.
+	polygonRPCURL = "https://ethereum-rpc.publicnode.com"
+	// polygonStakeManager is the proxy of the Polygon PoS StakeManager contract on Ethereum.
+	polygonStakeManager = "0x5e3Ef299fDDf15eAa0432E6e66473ace8c13D908"
+	// polygonStatusActive is the Active value of the StakeManager's validator status.
+	polygonStatusActive = 1
 )
 
-type PolygonResponse struct {
-	List []struct {
-		TotalStaked int64 `json:"totalStaked"`
-	} `json:"list"`
-}
+// Polygon returns the stake of the active Polygon PoS validators, read from the StakeManager contract on Ethereum.
+// The stake of a validator is its own stake plus the stake delegated to it.
+func Polygon() (Distribution, error) {
+	rpcURL := evmRPCURL(MATIC, polygonRPCURL)
+	block, err := evmLatestBlock(rpcURL)
+	if err != nil {
+		return Distribution{}, err
+	}
 
-func Polygon() (int, error) {
-	var votingPowers []int64
-	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelFunc()
+	stakeManager := evmContract{rpcURL: rpcURL, block: block, address: polygonStakeManager}
 
-	url := fmt.Sprintf("https://validator.info/api/polygon/validators?timeframe=week&nameContains=&activeValidators=true")
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	// Validator ids are the ids of the staking NFTs, from 1 up to the NFT counter.
+	res, err := stakeManager.call("NFTCounter()")
 	if err != nil {
-		log.Println(err)
-		return 0, errors.New("create get request for polygon")
+		return Distribution{}, err
 	}
-
-	resp, err := new(http.Client).Do(req)
+	counter, err := abiUintAt(res, 0)
 	if err != nil {
-		log.Println(err)
-		return 0, errors.New("get request unsuccessful")
+		return Distribution{}, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	res, err = stakeManager.call("currentEpoch()")
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
-	resp.Body.Close()
-
-	var response PolygonResponse
-	err = json.Unmarshal(body, &response)
+	currentEpoch, err := abiUintAt(res, 0)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
-	// Loop through the validators staked amounts
-	for _, ele := range response.List {
-		votingPowers = append(votingPowers, ele.TotalStaked)
+	var votingPowers []Validator
+	for id := int64(1); id < counter.Int64(); id++ {
+		res, err := stakeManager.call("validators(uint256)", abiUint(big.NewInt(id)))
+		if err != nil {
+			return Distribution{}, err
+		}
+
+		// The Validator struct is (amount, reward, activationEpoch, deactivationEpoch, jailTime, signer,
+		// contractAddress, status, commissionRate, lastCommissionUpdate, delegatorsReward, delegatedAmount,
+		// initialRewardPerStake).
+		status, err := abiUintAt(res, 7)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to decode validator %d: %w", id, err)
+		}
+		// Unbonding validators stay in the set until their deactivation epoch.
+		deactivationEpoch, err := abiUintAt(res, 3)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to decode validator %d: %w", id, err)
+		}
+		if status.Int64() != polygonStatusActive || (deactivationEpoch.Sign() != 0 && deactivationEpoch.Cmp(currentEpoch) <= 0) {
+			continue
+		}
+
+		amount, err := abiUintAt(res, 0)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to decode validator %d: %w", id, err)
+		}
+		delegatedAmount, err := abiUintAt(res, 11)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to decode validator %d: %w", id, err)
+		}
+		signer, err := abiAddressAt(res, 5)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("failed to decode validator %d: %w", id, err)
+		}
+
+		votingPowers = append(votingPowers, Validator{
+			Address:     signer,
+			VotingPower: new(big.Int).Add(amount, delegatedAmount),
+		})
 	}
 
-	// Sort the voting powers in descending order since they maybe in random order.
-	sort.Slice(votingPowers, func(i, j int) bool { return votingPowers[i] > votingPowers[j] })
+	// Cross-check the validators against the stake manager's own total.
+	res, err = stakeManager.call("currentValidatorSetTotalStake()")
+	if err != nil {
+		return Distribution{}, err
+	}
+	totalStake, err := abiUintAt(res, 0)
+	if err != nil {
+		return Distribution{}, err
+	}
 
-	totalVotingPower := utils.CalculateTotalVotingPower(votingPowers)
-	fmt.Println("Total voting power:", totalVotingPower)
+	sum := new(big.Int)
+	for _, v := range votingPowers {
+		sum.Add(sum, v.VotingPower)
+	}
+	if sum.Cmp(totalStake) != 0 {
+		log.Printf("Polygon validators stake %s differs from the current validator set stake %s", sum, totalStake)
+	}
 
-	// Now we're ready to calculate the nakamoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficient(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for 0xPolygon is", nakamotoCoefficient)
+	log.Printf("Fetched %d active Polygon validators at block %s", len(votingPowers), block)
 
-	return nakamotoCoefficient, nil
+	return Distribution{Validators: votingPowers}, nil
 }
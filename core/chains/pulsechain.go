@@ -1,63 +1,10 @@
 package chains
 
-import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+// pulsechainBeaconURL is a public beacon API used when BEACON_URL_PLS is not set.
+const pulsechainBeaconURL = "https://rpc-pulsechain.g4mm4.io/beacon-api"
 
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
-)
-
-type ApiResponse struct {
-	LastUpdated  string    `json:"last_updated"`
-	Validators   []int64   `json:"active_validator_balances"`
-}
-
-type ApiErrorResponse struct {
-	Code         int       `json:"code"`
-	Message      string    `json:"message"`
-}
-
-func Pulsechain() (int, error) {
-	url := fmt.Sprintf("https://api.korkey.tech/pulsechain/validator_data.json")
-	resp, err := http.Get(url)
-	if err != nil {
-		errBody, _ := ioutil.ReadAll(resp.Body)
-		var errResp ApiErrorResponse
-		
-		errr := json.Unmarshal(errBody, &errResp)
-		if errr != nil {
-			return 0, errr
-		}
-
-		return 0, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
-	}
-
-	var response ApiResponse
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return 0, err
-	}
-
-	// break if no entries
-	if len(response.Validators) == 0 {
-		return 0, err
-	}
-
-	totalStaked := utils.CalculateTotalVotingPower(response.Validators)
-	fmt.Println("Total voting power:", totalStaked)
-
-	// Now we're ready to calculate the nakamoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficient(totalStaked, response.Validators)
-	fmt.Println("The Nakamoto coefficient for Pulsechain is", nakamotoCoefficient)
-
-	return nakamotoCoefficient, nil
+// Pulsechain returns the effective balances of the active Pulsechain validators, grouped into entities
+// by withdrawal address like the validators of Ethereum.
+func Pulsechain() (Distribution, error) {
+	return FetchBeaconValidators(PLS, beaconURL(PLS, pulsechainBeaconURL), nil)
 }
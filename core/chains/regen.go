@@ -1,8 +1,8 @@
 package chains
 
-func Regen() (int, error) {
-	validatorURL := "https://regen.api.m.stavr.tech/cosmos/staking/v1beta1/validators?page.offset=1&pagination.limit=100&status=BOND_STATUS_BONDED"
+func Regen() (Distribution, error) {
+	validatorURL := "https://regen.api.m.stavr.tech/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED"
 	poolURL := "https://regen.api.m.stavr.tech/cosmos/staking/v1beta1/pool"
 
-	return FetchCosmosSDKNakaCoeff("regen", validatorURL, poolURL)
+	return FetchCosmosSDKNakaCoeff(REGEN, validatorURL, poolURL)
 }
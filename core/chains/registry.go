@@ -0,0 +1,210 @@
+package chains
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// registryChain is a Cosmos SDK chain defined in a local copy of the cosmos chain-registry
+// (https://github.com/cosmos/chain-registry).
+type registryChain struct {
+	ChainName    string `json:"chain_name"`
+	PrettyName   string `json:"pretty_name"`
+	Bech32Prefix string `json:"bech32_prefix"`
+	Staking      struct {
+		StakingTokens []struct {
+			Denom string `json:"denom"`
+		} `json:"staking_tokens"`
+	} `json:"staking"`
+	Apis struct {
+		Rest []registryEndpoint `json:"rest"`
+		Rpc  []registryEndpoint `json:"rpc"`
+	} `json:"apis"`
+
+	// Symbol and Decimals of the staking denom are read from the chain's assetlist.json.
+	Symbol   string `json:"-"`
+	Decimals int    `json:"-"`
+}
+
+type registryEndpoint struct {
+	Address  string `json:"address"`
+	Provider string `json:"provider"`
+}
+
+type registryAssetList struct {
+	Assets []struct {
+		Base       string `json:"base"`
+		Display    string `json:"display"`
+		Symbol     string `json:"symbol"`
+		DenomUnits []struct {
+			Denom    string `json:"denom"`
+			Exponent int    `json:"exponent"`
+		} `json:"denom_units"`
+	} `json:"assets"`
+}
+
+var (
+	registryMu     sync.RWMutex
+	registryChains = make(map[Token]registryChain)
+)
+
+// RegistryTokens loads the chains listed in CHAIN_REGISTRY_CHAINS from the chain-registry in
+// CHAIN_REGISTRY_DIR and returns their tokens. Chains whose token is already supported are skipped.
+func RegistryTokens() []Token {
+	names := os.Getenv("CHAIN_REGISTRY_CHAINS")
+	if names == "" {
+		return nil
+	}
+
+	dir := os.Getenv("CHAIN_REGISTRY_DIR")
+	if dir == "" {
+		dir = "chain-registry"
+	}
+
+	var (
+		tokens []Token
+		loaded = make(map[Token]registryChain)
+	)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		chain, err := loadRegistryChain(filepath.Join(dir, name))
+		if err != nil {
+			log.Printf("Failed to load %s from the chain registry: %v", name, err)
+			continue
+		}
+
+		token := Token(strings.ToUpper(chain.Symbol))
+		if _, ok := loaded[token]; ok || isBuiltinToken(token) {
+			log.Printf("Skipping %s from the chain registry: token %s is already supported", name, token)
+			continue
+		}
+
+		loaded[token] = chain
+		tokens = append(tokens, token)
+	}
+
+	registryMu.Lock()
+	registryChains = loaded
+	registryMu.Unlock()
+
+	return tokens
+}
+
+func loadRegistryChain(dir string) (registryChain, error) {
+	var chain registryChain
+
+	body, err := os.ReadFile(filepath.Join(dir, "chain.json"))
+	if err != nil {
+		return registryChain{}, err
+	}
+	if err := json.Unmarshal(body, &chain); err != nil {
+		return registryChain{}, fmt.Errorf("failed to parse chain.json: %w", err)
+	}
+	if len(chain.Staking.StakingTokens) == 0 {
+		return registryChain{}, errors.New("no staking token")
+	}
+
+	body, err = os.ReadFile(filepath.Join(dir, "assetlist.json"))
+	if err != nil {
+		return registryChain{}, err
+	}
+	var assets registryAssetList
+	if err := json.Unmarshal(body, &assets); err != nil {
+		return registryChain{}, fmt.Errorf("failed to parse assetlist.json: %w", err)
+	}
+
+	denom := chain.Staking.StakingTokens[0].Denom
+	for _, asset := range assets.Assets {
+		if asset.Base != denom {
+			continue
+		}
+
+		chain.Symbol = asset.Symbol
+		for _, unit := range asset.DenomUnits {
+			if unit.Denom == asset.Display {
+				chain.Decimals = unit.Exponent
+			}
+		}
+	}
+	if chain.Symbol == "" {
+		return registryChain{}, fmt.Errorf("staking denom %s not found in assetlist.json", denom)
+	}
+
+	return chain, nil
+}
+
+func lookupRegistryChain(token Token) (registryChain, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	chain, ok := registryChains[token]
+
+	return chain, ok
+}
+
+func isBuiltinToken(token Token) bool {
+	for _, t := range Tokens {
+		if t == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fetchRegistryChain fetches the distribution of a chain-registry chain, failing over through its
+// REST endpoints and then through its CometBFT RPC endpoints.
+func fetchRegistryChain(token Token, chain registryChain) (Distribution, error) {
+	var errs []error
+
+	for _, api := range chain.Apis.Rest {
+		baseURL := strings.TrimSuffix(api.Address, "/")
+		validatorURL := baseURL + "/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED"
+		poolURL := baseURL + "/cosmos/staking/v1beta1/pool"
+
+		dist, err := FetchCosmosSDKNakaCoeff(token, validatorURL, poolURL)
+		if err == nil {
+			logRegistryTotal(chain, dist)
+			return dist, nil
+		}
+
+		log.Printf("REST endpoint %s of %s failed: %v", api.Address, chain.ChainName, err)
+		errs = append(errs, err)
+	}
+
+	for _, api := range chain.Apis.Rpc {
+		dist, err := FetchCometBFTValidators(api.Address, 0)
+		if err == nil {
+			return dist, nil
+		}
+
+		log.Printf("RPC endpoint %s of %s failed: %v", api.Address, chain.ChainName, err)
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return Distribution{}, fmt.Errorf("no endpoints listed for %s", chain.ChainName)
+	}
+
+	return Distribution{}, fmt.Errorf("all endpoints of %s failed: %w", chain.ChainName, errors.Join(errs...))
+}
+
+// logRegistryTotal logs the bonded stake of the chain in its display unit.
+func logRegistryTotal(chain registryChain, dist Distribution) {
+	total := new(big.Int)
+	for _, v := range dist.Validators {
+		total.Add(total, v.VotingPower)
+	}
+
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(chain.Decimals)), nil))
+	display := new(big.Float).Quo(new(big.Float).SetInt(total), scale)
+
+	log.Printf("Total bonded stake for %s: %s %s", chain.PrettyName, display.Text('f', 0), chain.Symbol)
+}
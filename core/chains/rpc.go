@@ -0,0 +1,70 @@
+package chains
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+type jsonRPCResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// jsonRPC makes a JSON-RPC 2.0 call and decodes its result. Params are positional, as a slice, or named, as a map.
+func jsonRPC(url, method string, params interface{}, result interface{}) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFunc()
+
+	requestBody, err := json.Marshal(rawBody{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		log.Println(err)
+		return fmt.Errorf("create post request for %s", method)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return fmt.Errorf("post request unsuccessful for %s", method)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var response jsonRPCResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return fmt.Errorf("rpc error %d: %s", response.Error.Code, response.Error.Message)
+	}
+	if response.Result == nil {
+		return errors.New("rpc response without result")
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
@@ -0,0 +1,99 @@
+package chains
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// rpcHandler answers a JSON-RPC method of a stand-in node with a result, or with an error returned as the
+// error member of the response.
+type rpcHandler func(params json.RawMessage) (interface{}, error)
+
+// newJSONRPCStandIn starts a local JSON-RPC 2.0 server answering the given methods, standing in for a node.
+// Unknown methods are answered with the "method not found" error.
+func newJSONRPCStandIn(t *testing.T, handlers map[string]rpcHandler) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		handler, ok := handlers[request.Method]
+		if !ok {
+			response["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		} else if result, err := handler(request.Params); err != nil {
+			response["error"] = map[string]interface{}{"code": 3, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestJSONRPC(t *testing.T) {
+	server := newJSONRPCStandIn(t, map[string]rpcHandler{
+		"positional": func(raw json.RawMessage) (interface{}, error) {
+			var params []interface{}
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, err
+			}
+			return params, nil
+		},
+		"named": func(raw json.RawMessage) (interface{}, error) {
+			var params map[string]string
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, err
+			}
+			return params["finality"], nil
+		},
+		"null": func(json.RawMessage) (interface{}, error) {
+			return nil, nil
+		},
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		params  interface{}
+		want    string
+		wantErr bool
+	}{
+		{name: "positional params", method: "positional", params: []interface{}{"a", 1}, want: `["a",1]`},
+		{name: "named params", method: "named", params: map[string]string{"finality": "final"}, want: `"final"`},
+		{name: "error member", method: "missing", params: []interface{}{}, wantErr: true},
+		{name: "null result", method: "null", params: []interface{}{}, want: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result json.RawMessage
+			err := jsonRPC(server.URL, tt.method, tt.params, &result)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, expected an error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("result = %s, want %s", result, tt.want)
+			}
+		})
+	}
+}
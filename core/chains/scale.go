@@ -0,0 +1,192 @@
+package chains
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// This file implements the parts of the Substrate storage encoding needed to read staking exposures:
+// twox storage key hashing, SCALE compact integers and SS58 addresses.
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// twox128 is the Twox128 storage hasher, used for pallet and storage item prefixes.
+func twox128(data []byte) []byte {
+	out := make([]byte, 16)
+	binary.LittleEndian.PutUint64(out, xxhash64(data, 0))
+	binary.LittleEndian.PutUint64(out[8:], xxhash64(data, 1))
+
+	return out
+}
+
+// twox64Concat is the Twox64Concat storage hasher, which appends the key to its hash.
+func twox64Concat(data []byte) []byte {
+	out := make([]byte, 8, 8+len(data))
+	binary.LittleEndian.PutUint64(out, xxhash64(data, 0))
+
+	return append(out, data...)
+}
+
+// storagePrefix returns the storage key prefix of a pallet's storage item.
+func storagePrefix(pallet, item string) []byte {
+	return append(twox128([]byte(pallet)), twox128([]byte(item))...)
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+
+	return acc*xxPrime1 + xxPrime4
+}
+
+// xxhash64 is the XXH64 hash of data with the given seed.
+func xxhash64(data []byte, seed uint64) uint64 {
+	n := len(data)
+
+	var h uint64
+	if n >= 32 {
+		v1 := seed + xxPrime1 + xxPrime2
+		v2 := seed + xxPrime2
+		v3 := seed
+		v4 := seed - xxPrime1
+		for ; len(data) >= 32; data = data[32:] {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(data))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(data[24:]))
+		}
+
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = seed + xxPrime5
+	}
+
+	h += uint64(n)
+	for ; len(data) >= 8; data = data[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+
+	return h
+}
+
+// decodeCompact decodes a SCALE compact integer and returns it with the number of bytes read.
+func decodeCompact(data []byte) (*big.Int, int, error) {
+	if len(data) == 0 {
+		return nil, 0, errors.New("empty compact integer")
+	}
+
+	switch data[0] & 0b11 {
+	case 0b00:
+		return big.NewInt(int64(data[0] >> 2)), 1, nil
+	case 0b01:
+		if len(data) < 2 {
+			return nil, 0, errors.New("truncated compact integer")
+		}
+		return big.NewInt(int64(binary.LittleEndian.Uint16(data) >> 2)), 2, nil
+	case 0b10:
+		if len(data) < 4 {
+			return nil, 0, errors.New("truncated compact integer")
+		}
+		return big.NewInt(int64(binary.LittleEndian.Uint32(data) >> 2)), 4, nil
+	default:
+		n := int(data[0]>>2) + 4
+		if len(data) < 1+n {
+			return nil, 0, errors.New("truncated compact integer")
+		}
+
+		// The integer is little endian, while big.Int.SetBytes expects big endian.
+		be := make([]byte, n)
+		for i := 0; i < n; i++ {
+			be[n-1-i] = data[1+i]
+		}
+
+		return new(big.Int).SetBytes(be), 1 + n, nil
+	}
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// ss58Encode returns the SS58 address of a public key for the given network prefix.
+func ss58Encode(pubKey []byte, prefix uint16) (string, error) {
+	if prefix > 16383 {
+		return "", fmt.Errorf("invalid ss58 prefix %d", prefix)
+	}
+
+	var payload []byte
+	if prefix < 64 {
+		payload = []byte{byte(prefix)}
+	} else {
+		payload = []byte{
+			byte((prefix&0xfc)>>2) | 0x40,
+			byte(prefix>>8) | byte((prefix&0x03)<<6),
+		}
+	}
+	payload = append(payload, pubKey...)
+
+	checksum := blake2b.Sum512(append([]byte("SS58PRE"), payload...))
+	payload = append(payload, checksum[:2]...)
+
+	return base58Encode(payload), nil
+}
+
+func base58Encode(data []byte) string {
+	var (
+		n    = new(big.Int).SetBytes(data)
+		base = big.NewInt(58)
+		mod  = new(big.Int)
+		out  []byte
+	)
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return string(out)
+}
@@ -0,0 +1,127 @@
+package chains
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestXXHash64(t *testing.T) {
+	tests := []struct {
+		data string
+		want uint64
+	}{
+		{data: "", want: 0xef46db3751d8e999},
+		{data: "a", want: 0xd24ec4f1a98c6e5b},
+		{data: "abc", want: 0x44bc2cf5ad770999},
+	}
+
+	for _, tt := range tests {
+		if got := xxhash64([]byte(tt.data), 0); got != tt.want {
+			t.Errorf("xxhash64(%q) = %x, want %x", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestStoragePrefix(t *testing.T) {
+	tests := []struct {
+		pallet, item string
+		want         string
+	}{
+		{pallet: "Staking", item: "ActiveEra", want: "5f3e4907f716ac89b6347d15ececedca487df464e44a534ba6b0cbb32407b587"},
+		{pallet: "Staking", item: "ErasStakers", want: "5f3e4907f716ac89b6347d15ececedca8bde0a0ea8864605e3b68ed9cb2da01b"},
+		{pallet: "Staking", item: "ErasStakersOverview", want: "5f3e4907f716ac89b6347d15ececedca7493ea190d0af47acc70e25428f8b1a3"},
+	}
+
+	for _, tt := range tests {
+		if got := hex.EncodeToString(storagePrefix(tt.pallet, tt.item)); got != tt.want {
+			t.Errorf("storagePrefix(%s, %s) = %s, want %s", tt.pallet, tt.item, got, tt.want)
+		}
+	}
+}
+
+func TestTwox64ConcatEra(t *testing.T) {
+	tests := []struct {
+		era  uint32
+		want string
+	}{
+		{era: 0, want: "b4def25cfda6ef3a00000000"},
+		{era: 1, want: "5153cb1f00942ff401000000"},
+		{era: 1000, want: "b6ff6f7d467b87a9e8030000"},
+	}
+
+	for _, tt := range tests {
+		era := make([]byte, 4)
+		binary.LittleEndian.PutUint32(era, tt.era)
+		if got := hex.EncodeToString(twox64Concat(era)); got != tt.want {
+			t.Errorf("twox64Concat(%d) = %s, want %s", tt.era, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeCompact(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    string
+		read    int
+		wantErr bool
+	}{
+		{data: "00", want: "0", read: 1},
+		{data: "fc", want: "63", read: 1},
+		{data: "9101", want: "100", read: 2},
+		{data: "02093d00", want: "1000000", read: 4},
+		{data: "17000010632d5ec76b05", want: "100000000000000000000", read: 10},
+		{data: "", wantErr: true},
+		{data: "02093d", wantErr: true},
+		{data: "170000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.data)
+		got, read, err := decodeCompact(data)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("decodeCompact(%s) = %s, expected an error", tt.data, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("decodeCompact(%s): %v", tt.data, err)
+			continue
+		}
+
+		want, _ := new(big.Int).SetString(tt.want, 10)
+		if got.Cmp(want) != 0 || read != tt.read {
+			t.Errorf("decodeCompact(%s) = %s, %d, want %s, %d", tt.data, got, read, tt.want, tt.read)
+		}
+	}
+}
+
+func TestSS58Encode(t *testing.T) {
+	// The public key of the well-known development account Alice.
+	alice, _ := hex.DecodeString("d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d")
+
+	tests := []struct {
+		prefix  uint16
+		want    string
+		wantErr bool
+	}{
+		{prefix: 0, want: "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5"},
+		{prefix: 42, want: "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+		{prefix: 16384, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ss58Encode(alice, tt.prefix)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ss58Encode(prefix %d) = %s, expected an error", tt.prefix, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ss58Encode(prefix %d) = %s, %v, want %s", tt.prefix, got, err, tt.want)
+		}
+	}
+}
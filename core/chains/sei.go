@@ -1,8 +1,8 @@
 package chains
 
-func Sei() (int, error) {
-	validatorsURL := "https://rest.sei-apis.com/cosmos/staking/v1beta1/validators?page.offset=1&pagination.limit=100&status=BOND_STATUS_BONDED"
+func Sei() (Distribution, error) {
+	validatorsURL := "https://rest.sei-apis.com/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED"
 	stakingPoolURL := "https://rest.sei-apis.com/cosmos/staking/v1beta1/pool"
 
-	return FetchCosmosSDKNakaCoeff("sei", validatorsURL, stakingPoolURL)
+	return FetchCosmosSDKNakaCoeff(SEI, validatorsURL, stakingPoolURL)
 }
@@ -1,77 +1,206 @@
 package chains
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/big"
 	"net/http"
 	"os"
-	"sort"
-
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
+	"strings"
+	"time"
 )
 
+// SolanaResponse is the validator list of validators.app.
 type SolanaResponse []struct {
 	Name         string `json:"name"`
 	Account      string `json:"keybase_id"`
+	VoteAccount  string `json:"vote_account"`
 	Active_stake int64  `json:"active_stake"`
 	Delinquent   bool   `json:"delinquent"`
+	// DataCenterKey identifies the data center as <ASN>-<country code>-<city>, for example 24940-DE-Falkenstein.
+	DataCenterKey          string `json:"data_center_key"`
+	AutonomousSystemNumber int    `json:"autonomous_system_number"`
 }
 
-func Solana() (int, error) {
-	url := fmt.Sprintf("https://www.validators.app/api/v1/validators/mainnet.json")
+type solanaVoteAccount struct {
+	VotePubkey     string      `json:"votePubkey"`
+	NodePubkey     string      `json:"nodePubkey"`
+	ActivatedStake json.Number `json:"activatedStake"`
+}
 
-	var votingPowers []big.Int
+type solanaVoteAccounts struct {
+	Current    []solanaVoteAccount `json:"current"`
+	Delinquent []solanaVoteAccount `json:"delinquent"`
+}
 
-	// Create a new request using http
-	req, err := http.NewRequest("GET", url, nil)
+// Solana returns the activated stake of the vote accounts from the getVoteAccounts method of SOLANA_RPC_URL.
+// Only the stake of the vote accounts that are currently voting is counted, while the stake of all of them,
+// delinquent ones included, is returned as the including_delinquent alternative. The vote accounts are named
+// after their validators.app entry if SOLANA_API_KEY is set. The share of the current epoch's leader slots of
+// each validator identity is returned as the leader_slots alternative.
+func Solana() (Distribution, error) {
+	var voteAccounts solanaVoteAccounts
+	if err := jsonRPC(solanaRPCURL(), "getVoteAccounts", []interface{}{}, &voteAccounts); err != nil {
+		return Distribution{}, fmt.Errorf("failed to fetch vote accounts: %w", err)
+	}
 
-	// Add authorization header to the request
-	// NOTE: You can get your own API_KEY from https://www.validators.app/api-documentation
-	req.Header.Add("Token", os.Getenv("SOLANA_API_KEY"))
+	// validators.app only provides names and hosting, so failing to reach it is not fatal.
+	var (
+		names     = make(map[string]string)
+		groupings map[string]EntityMapping
+	)
+	if apiKey := os.Getenv("SOLANA_API_KEY"); apiKey != "" {
+		validators, err := fetchValidatorsApp(apiKey)
+		if err != nil {
+			log.Printf("Failed to fetch validators.app data for solana: %v", err)
+		}
+		for _, v := range validators {
+			names[v.VoteAccount] = v.Name
+		}
+		groupings = solanaHostingGroupings(validators)
+	}
 
-	// Send req using http Client
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Println(err)
-		return 0, err
+	var (
+		votingPowers    []Validator
+		allVotingPowers []Validator
+		current         = new(big.Int)
+		delinquent      = new(big.Int)
+		delinquentVotes = make(map[string]bool)
+		identityNames   = make(map[string]string)
+	)
+	for _, acc := range voteAccounts.Delinquent {
+		delinquentVotes[acc.VotePubkey] = true
 	}
-	defer resp.Body.Close()
+	for _, acc := range append(voteAccounts.Current, voteAccounts.Delinquent...) {
+		stake, ok := new(big.Int).SetString(acc.ActivatedStake.String(), 10)
+		if !ok {
+			return Distribution{}, fmt.Errorf("failed to parse activated stake %q of %s", acc.ActivatedStake, acc.VotePubkey)
+		}
 
-	body, err := io.ReadAll(resp.Body)
+		v := Validator{Address: acc.VotePubkey, Name: names[acc.VotePubkey], VotingPower: stake}
+		identityNames[acc.NodePubkey] = v.Name
+		allVotingPowers = append(allVotingPowers, v)
+		if delinquentVotes[acc.VotePubkey] {
+			delinquent.Add(delinquent, stake)
+			continue
+		}
+
+		votingPowers = append(votingPowers, v)
+		current.Add(current, stake)
+	}
+
+	log.Printf("Solana stake: %s lamports current in %d vote accounts, %s lamports delinquent in %d vote accounts",
+		current, len(votingPowers), delinquent, len(voteAccounts.Delinquent))
+
+	// Stake pools are optional, so failing to resolve their delegations is not fatal.
+	delegations, err := fetchSolanaControllerDelegations()
 	if err != nil {
-		return 0, err
+		log.Printf("Failed to fetch stake pool delegations for solana: %v", err)
 	}
 
-	var response SolanaResponse
-	err = json.Unmarshal(body, &response)
+	dist := Distribution{
+		Validators:   votingPowers,
+		Delegations:  delegations,
+		Groupings:    groupings,
+		Alternatives: map[string]Distribution{"including_delinquent": {Validators: allVotingPowers}},
+	}
+
+	leaderSlots, err := fetchSolanaLeaderSlots(identityNames)
 	if err != nil {
-		return 0, err
+		log.Printf("Failed to fetch leader schedule for solana: %v", err)
+	} else {
+		dist.Alternatives["leader_slots"] = leaderSlots
 	}
 
-	// loop through the validators voting powers
-	for _, ele := range response {
-		votingPowers = append(votingPowers, *big.NewInt(ele.Active_stake))
+	return dist, nil
+}
+
+// solanaHostingGroupings maps the vote accounts to their data center, autonomous system and country.
+// Vote accounts with unknown hosting are left out, so they count as their own provider.
+func solanaHostingGroupings(validators SolanaResponse) map[string]EntityMapping {
+	groupings := map[string]EntityMapping{
+		"data_center": make(EntityMapping),
+		"asn":         make(EntityMapping),
+		"country":     make(EntityMapping),
 	}
 
-	// need to sort the powers in descending order since they are in random order
-	sort.Slice(votingPowers, func(i, j int) bool {
-		res := (&votingPowers[i]).Cmp(&votingPowers[j])
-		if res == 1 {
-			return true
+	for _, v := range validators {
+		// Unknown hosting is reported as the data center "0--Unknown", with ASN 0 and no country.
+		parts := strings.SplitN(v.DataCenterKey, "-", 3)
+		if len(parts) == 3 && parts[0] != "0" && parts[0] != "" && parts[2] != "Unknown" {
+			groupings["data_center"][v.VoteAccount] = v.DataCenterKey
+		}
+		if v.AutonomousSystemNumber != 0 {
+			groupings["asn"][v.VoteAccount] = fmt.Sprintf("AS%d", v.AutonomousSystemNumber)
 		}
-		return false
-	})
+		if len(parts) == 3 && parts[1] != "" {
+			groupings["country"][v.VoteAccount] = parts[1]
+		}
+	}
 
-	totalVotingPower := utils.CalculateTotalVotingPowerBigNums(votingPowers)
-	fmt.Println("Total voting power:", new(big.Float).SetInt(totalVotingPower))
+	return groupings
+}
 
-	// now we're ready to calculate the nakomoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for Solana is", nakamotoCoefficient)
+// fetchSolanaLeaderSlots returns the number of slots each validator identity leads in the current epoch,
+// from the stake-weighted leader schedule.
+func fetchSolanaLeaderSlots(names map[string]string) (Distribution, error) {
+	var schedule map[string][]uint64
+	if err := jsonRPC(solanaRPCURL(), "getLeaderSchedule", []interface{}{}, &schedule); err != nil {
+		return Distribution{}, err
+	}
+	if len(schedule) == 0 {
+		return Distribution{}, errors.New("empty leader schedule")
+	}
+
+	var slots []Validator
+	for identity, leaderSlots := range schedule {
+		slots = append(slots, Validator{
+			Address:     identity,
+			Name:        names[identity],
+			VotingPower: big.NewInt(int64(len(leaderSlots))),
+		})
+	}
+
+	return Distribution{Validators: slots}, nil
+}
+
+// fetchValidatorsApp returns the Solana mainnet validators listed by validators.app.
+// NOTE: You can get your own API_KEY from https://www.validators.app/api-documentation
+func fetchValidatorsApp(apiKey string) (SolanaResponse, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.validators.app/api/v1/validators/mainnet.json", nil)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("create get request for validators.app")
+	}
+	req.Header.Set("Token", apiKey)
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("get request unsuccessful for validators.app")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("validators.app request failed: %d", resp.StatusCode)
+	}
+
+	var response SolanaResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
 
-	return nakamotoCoefficient, nil
+	return response, nil
 }
@@ -0,0 +1,160 @@
+package chains
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	solanaDefaultRPCURL = "https://api.mainnet-beta.solana.com"
+	solanaStakeProgram  = "Stake11111111111111111111111111111111111111"
+	// solanaStakerOffset is the offset of the stake authority in a stake account.
+	solanaStakerOffset = 12
+	// solanaNotDeactivated is the deactivation epoch of stake that is not deactivating.
+	solanaNotDeactivated = "18446744073709551615"
+)
+
+type solanaStakeAccountsResponse struct {
+	Result []struct {
+		Pubkey  string `json:"pubkey"`
+		Account struct {
+			Data struct {
+				Parsed struct {
+					Type string `json:"type"`
+					Info struct {
+						Stake struct {
+							Delegation struct {
+								Voter             string `json:"voter"`
+								Stake             string `json:"stake"`
+								DeactivationEpoch string `json:"deactivationEpoch"`
+							} `json:"delegation"`
+						} `json:"stake"`
+					} `json:"info"`
+				} `json:"parsed"`
+			} `json:"data"`
+		} `json:"account"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// solanaRPCURL returns the Solana JSON-RPC endpoint, configurable through SOLANA_RPC_URL.
+func solanaRPCURL() string {
+	if url := os.Getenv("SOLANA_RPC_URL"); url != "" {
+		return url
+	}
+
+	return solanaDefaultRPCURL
+}
+
+// fetchSolanaControllerDelegations returns the stake the known stake pools delegated to vote accounts.
+// Stake pools are identified by the stake authority of their stake accounts.
+func fetchSolanaControllerDelegations() ([]Delegation, error) {
+	controllers, err := loadControllers(SOL)
+	if err != nil || len(controllers) == 0 {
+		return nil, err
+	}
+
+	var delegations []Delegation
+	for _, controller := range controllers {
+		for _, authority := range controller.Delegators {
+			accounts, err := fetchSolanaStakeAccounts(solanaRPCURL(), authority)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch stake accounts of %s: %w", controller.Name, err)
+			}
+
+			for _, acc := range accounts.Result {
+				if acc.Account.Data.Parsed.Type != "delegated" {
+					continue
+				}
+
+				delegation := acc.Account.Data.Parsed.Info.Stake.Delegation
+				if delegation.DeactivationEpoch != solanaNotDeactivated {
+					continue
+				}
+
+				stake, ok := new(big.Int).SetString(delegation.Stake, 10)
+				if !ok {
+					return nil, fmt.Errorf("failed to parse stake %s of %s", delegation.Stake, acc.Pubkey)
+				}
+
+				delegations = append(delegations, Delegation{
+					Controller: controller.Name,
+					Validator:  delegation.Voter,
+					Amount:     stake,
+				})
+			}
+		}
+	}
+
+	return delegations, nil
+}
+
+// fetchSolanaStakeAccounts returns the stake accounts with the given stake authority.
+func fetchSolanaStakeAccounts(url, authority string) (solanaStakeAccountsResponse, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFunc()
+
+	request := rawBody{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "getProgramAccounts",
+		Params: []interface{}{
+			solanaStakeProgram,
+			map[string]interface{}{
+				"encoding": "jsonParsed",
+				"filters": []interface{}{
+					map[string]interface{}{
+						"memcmp": map[string]interface{}{"offset": solanaStakerOffset, "bytes": authority},
+					},
+				},
+			},
+		},
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return solanaStakeAccountsResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		log.Println(err)
+		return solanaStakeAccountsResponse{}, errors.New("create post request for solana stake accounts")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return solanaStakeAccountsResponse{}, errors.New("post request unsuccessful for solana stake accounts")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return solanaStakeAccountsResponse{}, err
+	}
+
+	var response solanaStakeAccountsResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return solanaStakeAccountsResponse{}, err
+	}
+	if response.Error != nil {
+		return solanaStakeAccountsResponse{}, fmt.Errorf("rpc error %d: %s", response.Error.Code, response.Error.Message)
+	}
+
+	return response, nil
+}
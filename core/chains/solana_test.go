@@ -0,0 +1,132 @@
+package chains
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSolana(t *testing.T) {
+	voteAccounts := map[string]interface{}{
+		"current": []map[string]interface{}{
+			{"votePubkey": "vote1", "nodePubkey": "node1", "activatedStake": 25_000_000_000_000_000},
+			{"votePubkey": "vote2", "nodePubkey": "node2", "activatedStake": 25_000_000_000_000_000},
+			{"votePubkey": "vote3", "nodePubkey": "node3", "activatedStake": 25_000_000_000_000_000},
+			{"votePubkey": "vote4", "nodePubkey": "node4", "activatedStake": 25_000_000_000_000_000},
+		},
+		// The delinquent stake alone would exceed the threshold if it were counted.
+		"delinquent": []map[string]interface{}{
+			{"votePubkey": "vote5", "nodePubkey": "node5", "activatedStake": 100_000_000_000_000_000},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		handlers    map[string]rpcHandler
+		want        int
+		wantMetrics map[string]int
+		wantErr     bool
+		validators  int
+	}{
+		{
+			name: "vote accounts and leader schedule",
+			handlers: map[string]rpcHandler{
+				"getVoteAccounts": func(json.RawMessage) (interface{}, error) { return voteAccounts, nil },
+				"getLeaderSchedule": func(json.RawMessage) (interface{}, error) {
+					return map[string][]int{"node1": {0, 1}, "node2": {2, 3}, "node3": {4, 5}, "node4": {6, 7}}, nil
+				},
+			},
+			want:        2,
+			wantMetrics: map[string]int{"including_delinquent": 1, "leader_slots": 2},
+			validators:  4,
+		},
+		{
+			name: "no leader schedule",
+			handlers: map[string]rpcHandler{
+				"getVoteAccounts":   func(json.RawMessage) (interface{}, error) { return voteAccounts, nil },
+				"getLeaderSchedule": func(json.RawMessage) (interface{}, error) { return nil, errors.New("unavailable") },
+			},
+			want:        2,
+			wantMetrics: map[string]int{"including_delinquent": 1},
+			validators:  4,
+		},
+		{
+			name: "vote accounts unavailable",
+			handlers: map[string]rpcHandler{
+				"getVoteAccounts": func(json.RawMessage) (interface{}, error) { return nil, errors.New("node is behind") },
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SOLANA_RPC_URL", newJSONRPCStandIn(t, tt.handlers).URL)
+			t.Setenv("SOLANA_API_KEY", "")
+			t.Setenv("CONTROLLERS_DIR", t.TempDir())
+
+			dist, err := Solana()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(dist.Validators) != tt.validators {
+				t.Errorf("got %d validators, want %d", len(dist.Validators), tt.validators)
+			}
+			if len(dist.Candidates) != 0 {
+				t.Errorf("got %d candidates, want none", len(dist.Candidates))
+			}
+			if val, _, err := dist.Calculate(); err != nil || val != tt.want {
+				t.Errorf("coefficient = %d, %v, want %d", val, err, tt.want)
+			}
+
+			if len(dist.Alternatives) != len(tt.wantMetrics) {
+				t.Errorf("got alternatives %v, want %v", dist.Alternatives, tt.wantMetrics)
+			}
+			for name, want := range tt.wantMetrics {
+				val, _, err := dist.Alternatives[name].Calculate()
+				if err != nil || val != want {
+					t.Errorf("%s coefficient = %d, %v, want %d", name, val, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSolanaHostingGroupings(t *testing.T) {
+	validators := SolanaResponse{
+		{VoteAccount: "hetzner", DataCenterKey: "24940-DE-Falkenstein", AutonomousSystemNumber: 24940},
+		{VoteAccount: "unknown", DataCenterKey: "0--Unknown"},
+		{VoteAccount: "unknown city", DataCenterKey: "16509-US-Unknown", AutonomousSystemNumber: 16509},
+		{VoteAccount: "no key"},
+	}
+
+	tests := []struct {
+		grouping string
+		want     map[string]string
+	}{
+		{grouping: "data_center", want: map[string]string{"hetzner": "24940-DE-Falkenstein"}},
+		{grouping: "asn", want: map[string]string{"hetzner": "AS24940", "unknown city": "AS16509"}},
+		{grouping: "country", want: map[string]string{"hetzner": "DE", "unknown city": "US"}},
+	}
+
+	groupings := solanaHostingGroupings(validators)
+	for _, tt := range tests {
+		got := groupings[tt.grouping]
+		if len(got) != len(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.grouping, got, tt.want)
+			continue
+		}
+		for account, value := range tt.want {
+			if got[account] != value {
+				t.Errorf("%s of %s = %q, want %q", tt.grouping, account, got[account], value)
+			}
+		}
+	}
+}
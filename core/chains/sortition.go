@@ -0,0 +1,93 @@
+package chains
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// captureCertainty is the capture probability above which larger coalitions are not listed,
+// since they capture every committee in practice.
+const captureCertainty = 1 - 1e-9
+
+// Committee describes the committees of a chain that samples voters in proportion to their stake each round,
+// such as Algorand's cryptographic sortition. Polkadot and Avail have no such committees, since their block
+// production lottery is not weighted by stake and all elected validators vote on finality.
+type Committee struct {
+	// Size is the expected number of votes in a committee.
+	Size float64
+	// Threshold is the number of votes a committee needs to reach a decision.
+	Threshold int
+}
+
+// CaptureProbabilities returns, for k = 1, 2, ..., the probability that the k validators with the most voting
+// power alone cast at least the threshold of votes of the committee sampled in a single round. Each unit of stake
+// is selected independently with probability Size / total voting power, so the votes of a coalition follow a
+// Poisson distribution for stakes as large as those of real chains. The list ends at the first coalition whose
+// probability exceeds captureCertainty, or with all validators.
+func (d Distribution) CaptureProbabilities(c Committee) ([]float64, error) {
+	if c.Size <= 0 || c.Threshold <= 0 {
+		return nil, fmt.Errorf("invalid committee size %g and threshold %d", c.Size, c.Threshold)
+	}
+
+	validators := d.sorted()
+	total := d.TotalVotingPower
+	if total == nil {
+		total = new(big.Int)
+		for _, v := range validators {
+			total.Add(total, v.VotingPower)
+		}
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("total voting power is zero")
+	}
+
+	var (
+		probabilities []float64
+		stake         = new(big.Int)
+	)
+	for _, v := range validators {
+		stake.Add(stake, v.VotingPower)
+		share, _ := new(big.Rat).SetFrac(stake, total).Float64()
+
+		p := poissonTail(c.Size*share, c.Threshold)
+		probabilities = append(probabilities, p)
+		if p > captureCertainty {
+			break
+		}
+	}
+
+	return probabilities, nil
+}
+
+// poissonTail returns the probability that a Poisson variable with mean lambda is at least n.
+// The terms are summed on the side of the mean where they are small, so that both tails stay accurate.
+func poissonTail(lambda float64, n int) float64 {
+	if lambda <= 0 {
+		return 0
+	}
+
+	term := func(i int) float64 {
+		lgamma, _ := math.Lgamma(float64(i) + 1)
+		return math.Exp(float64(i)*math.Log(lambda) - lambda - lgamma)
+	}
+
+	if lambda < float64(n) {
+		// The terms decrease from n on, so the upper tail is summed until they no longer matter.
+		var sum float64
+		for i := n; ; i++ {
+			t := term(i)
+			sum += t
+			if t < sum*1e-17 || t == 0 {
+				return math.Min(sum, 1)
+			}
+		}
+	}
+
+	var lower float64
+	for i := 0; i < n; i++ {
+		lower += term(i)
+	}
+
+	return math.Max(1-lower, 0)
+}
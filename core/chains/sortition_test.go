@@ -0,0 +1,108 @@
+package chains
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// The expected tails were computed by summing the Poisson terms with 80 digit decimals.
+func TestPoissonTail(t *testing.T) {
+	tests := []struct {
+		name   string
+		lambda float64
+		n      int
+		want   float64
+	}{
+		{name: "upper tail", lambda: 1, n: 3, want: 8.03013970713941927e-02},
+		{name: "upper tail of more terms", lambda: 2, n: 5, want: 5.26530173437111601e-02},
+		{name: "far upper tail", lambda: 750, n: 1112, want: 3.77555495913066460e-35},
+		{name: "upper tail near the threshold", lambda: 1050, n: 1112, want: 2.97191831728713385e-02},
+		{name: "lower sum", lambda: 5, n: 3, want: 8.75347980516918867e-01},
+		{name: "lower sum at the mean", lambda: 3, n: 3, want: 5.76809918873156469e-01},
+		{name: "lower sum above the threshold", lambda: 1200, n: 1112, want: 9.95092941727028446e-01},
+		{name: "lower sum near certainty", lambda: 1350, n: 1112, want: 9.99999999989084065e-01},
+		{name: "underflowing tail", lambda: 150, n: 1112, want: 0},
+		{name: "no stake", lambda: 0, n: 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := poissonTail(tt.lambda, tt.n)
+			if math.Abs(got-tt.want) > 1e-9*tt.want || (tt.want == 0 && got != 0) {
+				t.Errorf("poissonTail(%g, %d) = %.17e, want %.17e", tt.lambda, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureProbabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		votingPowers []int64
+		committee    Committee
+		// want lists the expected probabilities from the index first.
+		from    int
+		want    []float64
+		count   int
+		wantErr bool
+	}{
+		{
+			// The ninth coalition, with 90% of the stake, exceeds captureCertainty, so the list ends there.
+			name:         "cut off at certainty",
+			votingPowers: []int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10},
+			committee:    algorandCertCommittee,
+			from:         6,
+			want:         []float64{2.97191831728713385e-02, 9.95092941727028446e-01, 9.99999999989084065e-01},
+			count:        9,
+		},
+		{
+			name:         "all validators listed",
+			votingPowers: []int64{50, 50},
+			committee:    Committee{Size: 10, Threshold: 8},
+			want:         []float64{1.33371674070007301e-01, 7.79779353398301067e-01},
+			count:        2,
+		},
+		{
+			name:         "empty committee",
+			votingPowers: []int64{50, 50},
+			committee:    Committee{Size: 0, Threshold: 8},
+			wantErr:      true,
+		},
+		{
+			name:         "no stake",
+			votingPowers: []int64{0, 0},
+			committee:    algorandCertCommittee,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dist Distribution
+			for _, vp := range tt.votingPowers {
+				dist.Validators = append(dist.Validators, Validator{VotingPower: big.NewInt(vp)})
+			}
+
+			got, err := dist.CaptureProbabilities(tt.committee)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, expected an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != tt.count {
+				t.Fatalf("got %d probabilities, want %d: %v", len(got), tt.count, got)
+			}
+			for i, want := range tt.want {
+				if p := got[tt.from+i]; math.Abs(p-want) > 1e-9*want {
+					t.Errorf("probability of the %d largest = %.17e, want %.17e", tt.from+i+1, p, want)
+				}
+			}
+		})
+	}
+}
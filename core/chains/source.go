@@ -0,0 +1,63 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configSource returns the path or URL of a per-chain configuration file, if any.
+// It is taken from the <envPrefix><TOKEN> variable, then from <dir>/<token>.json where dir
+// is read from dirEnv and defaults to defaultDir.
+func configSource(token Token, envPrefix, dirEnv, defaultDir string) string {
+	if source := os.Getenv(envPrefix + string(token)); source != "" {
+		return source
+	}
+
+	dir := os.Getenv(dirEnv)
+	if dir == "" {
+		dir = defaultDir
+	}
+	path := filepath.Join(dir, strings.ToLower(string(token))+".json")
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	return ""
+}
+
+// readSource reads a configuration file from a file path or an http(s) URL.
+func readSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("create get request for " + source)
+	}
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("get request unsuccessful for " + source)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch of %s failed: %d", source, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
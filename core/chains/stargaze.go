@@ -1,8 +1,8 @@
 package chains
 
-func Stargaze() (int, error) {
-	validatorsURL := "https://rest.stargaze-apis.com/cosmos/staking/v1beta1/validators?page.offset=1&pagination.limit=500&status=BOND_STATUS_BONDED"
+func Stargaze() (Distribution, error) {
+	validatorsURL := "https://rest.stargaze-apis.com/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED"
 	stakingPoolURL := "https://rest.stargaze-apis.com/cosmos/staking/v1beta1/pool"
 
-	return FetchCosmosSDKNakaCoeff("stargaze", validatorsURL, stakingPoolURL)
+	return FetchCosmosSDKNakaCoeff(STARS, validatorsURL, stakingPoolURL)
 }
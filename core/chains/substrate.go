@@ -0,0 +1,195 @@
+package chains
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscanPageRows is the number of validators requested per page, the maximum allowed by Subscan.
+const subscanPageRows = 100
+
+type subscanValidatorsResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Count int `json:"count"`
+		List  []struct {
+			BondedTotal         string `json:"bonded_total"`
+			StashAccountDisplay struct {
+				Address string `json:"address"`
+			} `json:"stash_account_display"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+var (
+	substrateMu     sync.RWMutex
+	substrateChains = make(map[Token]string)
+)
+
+// SubstrateTokens loads the Substrate chains listed in SUBSCAN_CHAINS and returns their tokens.
+// Each entry maps a token to a Subscan network or API URL, for example KSM=kusama or
+// KSM=https://kusama.api.subscan.io. Chains whose token is already supported are skipped.
+func SubstrateTokens() []Token {
+	entries := os.Getenv("SUBSCAN_CHAINS")
+	if entries == "" {
+		return nil
+	}
+
+	var (
+		tokens []Token
+		loaded = make(map[Token]string)
+	)
+	for _, entry := range strings.Split(entries, ",") {
+		t, network, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || t == "" || network == "" {
+			log.Printf("Skipping Subscan chain %q: expected TOKEN=network", entry)
+			continue
+		}
+
+		token := Token(strings.ToUpper(t))
+		if _, ok := loaded[token]; ok || isBuiltinToken(token) {
+			log.Printf("Skipping Subscan chain %s: token %s is already supported", network, token)
+			continue
+		}
+
+		loaded[token] = network
+		tokens = append(tokens, token)
+	}
+
+	substrateMu.Lock()
+	substrateChains = loaded
+	substrateMu.Unlock()
+
+	return tokens
+}
+
+func lookupSubstrateChain(token Token) (string, bool) {
+	substrateMu.RLock()
+	defer substrateMu.RUnlock()
+
+	network, ok := substrateChains[token]
+
+	return network, ok
+}
+
+// subscanAPIURL returns the Subscan API URL of a network, which may already be a URL.
+func subscanAPIURL(network string) string {
+	if strings.HasPrefix(network, "http://") || strings.HasPrefix(network, "https://") {
+		return strings.TrimSuffix(network, "/")
+	}
+
+	return fmt.Sprintf("https://%s.api.subscan.io", network)
+}
+
+// substrateChainName returns the display name of a configured Substrate chain, taken from the
+// network name or from the first label of the API host, for example Kusama for kusama.api.subscan.io.
+func substrateChainName(network string) string {
+	if u, err := url.Parse(network); err == nil && u.Host != "" {
+		network, _, _ = strings.Cut(u.Hostname(), ".")
+	}
+
+	return strings.ToUpper(network[:1]) + network[1:]
+}
+
+// FetchSubscanValidators returns the bonded stake of the validators of a Substrate chain
+// from the Subscan API at apiURL, following pagination. The key in SUBSCAN_API_KEY is sent if set.
+func FetchSubscanValidators(token Token, apiURL string) (Distribution, error) {
+	var (
+		votingPowers []Validator
+		count        int
+	)
+
+	for page := 0; ; page++ {
+		response, err := fetchSubscanValidatorsPage(apiURL+"/api/scan/staking/validators", page)
+		if err != nil {
+			return Distribution{}, err
+		}
+
+		count = response.Data.Count
+		for _, ele := range response.Data.List {
+			bondedTotal, ok := new(big.Int).SetString(ele.BondedTotal, 10)
+			if !ok {
+				return Distribution{}, fmt.Errorf("failed to parse bonded total %q of %s", ele.BondedTotal, ele.StashAccountDisplay.Address)
+			}
+
+			votingPowers = append(votingPowers, Validator{
+				Address:     ele.StashAccountDisplay.Address,
+				VotingPower: bondedTotal,
+			})
+		}
+
+		if len(response.Data.List) < subscanPageRows || len(votingPowers) >= count {
+			break
+		}
+	}
+
+	if len(votingPowers) != count {
+		return Distribution{}, fmt.Errorf("fetched %d validators but subscan reports %d", len(votingPowers), count)
+	}
+
+	log.Printf("Fetched %d validators of %s from %s", len(votingPowers), token.ChainName(), apiURL)
+
+	return Distribution{Validators: votingPowers}, nil
+}
+
+func fetchSubscanValidatorsPage(url string, page int) (subscanValidatorsResponse, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"order":       "desc",
+		"order_field": "bonded_total",
+		"row":         subscanPageRows,
+		"page":        page,
+	})
+	if err != nil {
+		return subscanValidatorsResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+	if err != nil {
+		log.Println(err)
+		return subscanValidatorsResponse{}, errors.New("create post request for subscan validators")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey := os.Getenv("SUBSCAN_API_KEY"); apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		log.Println(err)
+		return subscanValidatorsResponse{}, errors.New("post request unsuccessful for subscan validators")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return subscanValidatorsResponse{}, err
+	}
+
+	var response subscanValidatorsResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return subscanValidatorsResponse{}, err
+	}
+	if response.Code != 0 {
+		return subscanValidatorsResponse{}, fmt.Errorf("subscan error %d: %s", response.Code, response.Message)
+	}
+
+	return response, nil
+}
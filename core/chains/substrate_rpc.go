@@ -0,0 +1,212 @@
+package chains
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	// substrateKeysPage is the number of storage keys requested per state_getKeysPaged call.
+	substrateKeysPage = 1000
+	// substrateValuesBatch is the number of storage values requested per state_queryStorageAt call.
+	substrateValuesBatch = 200
+	// substrateAccountLen is the length of an AccountId32, the last part of an exposure storage key.
+	substrateAccountLen = 32
+)
+
+// substrateRPCChains are the chains whose data can be read from a Substrate node instead of Subscan.
+var substrateRPCChains = map[Token]bool{
+	AVAIL: true,
+	DOT:   true,
+}
+
+type substrateStorageChangeSet struct {
+	Block   string       `json:"block"`
+	Changes [][2]*string `json:"changes"`
+}
+
+// substrateRPCURL returns the Substrate node RPC endpoint configured for the chain through SUBSTRATE_RPC_<TOKEN>, if any.
+func substrateRPCURL(token Token) string {
+	if _, ok := lookupSubstrateChain(token); !ok && !substrateRPCChains[token] {
+		return ""
+	}
+
+	return os.Getenv("SUBSTRATE_RPC_" + string(token))
+}
+
+// FetchSubstrateExposures returns the backing stake of the validators elected in the given era, or in the
+// active era if negative, from the Staking pallet storage of a Substrate node. The paged ErasStakersOverview
+// exposures are used, falling back to the legacy ErasStakers ones for runtimes without them. All storage is
+// read at the same finalized block. For the active era, the validators already elected for the next era are
+// returned as the next epoch's set.
+func FetchSubstrateExposures(rpcURL string, era int64) (Distribution, error) {
+	var head string
+	if err := jsonRPC(rpcURL, "chain_getFinalizedHead", []interface{}{}, &head); err != nil {
+		return Distribution{}, fmt.Errorf("failed to fetch finalized head: %w", err)
+	}
+
+	var props struct {
+		SS58Format *uint16 `json:"ss58Format"`
+	}
+	if err := jsonRPC(rpcURL, "system_properties", []interface{}{}, &props); err != nil {
+		return Distribution{}, fmt.Errorf("failed to fetch chain properties: %w", err)
+	}
+	// 42 is the generic Substrate prefix, used by chains that do not define their own.
+	ss58Prefix := uint16(42)
+	if props.SS58Format != nil {
+		ss58Prefix = *props.SS58Format
+	}
+
+	var nextEra int64
+	if era < 0 {
+		activeEra, err := fetchSubstrateActiveEra(rpcURL, head)
+		if err != nil {
+			return Distribution{}, err
+		}
+		era, nextEra = int64(activeEra), int64(activeEra)+1
+	}
+
+	votingPowers, err := fetchSubstrateEraExposures(rpcURL, head, era, ss58Prefix)
+	if err != nil {
+		return Distribution{}, err
+	}
+	if len(votingPowers) == 0 {
+		return Distribution{}, fmt.Errorf("no exposures found for era %d", era)
+	}
+
+	log.Printf("Fetched %d exposures of era %d at block %s from %s", len(votingPowers), era, head, rpcURL)
+
+	// The validators of the next era are stored once they are elected, during the last session of the active era.
+	var nextEpoch []Validator
+	if nextEra > 0 {
+		nextEpoch, err = fetchSubstrateEraExposures(rpcURL, head, nextEra, ss58Prefix)
+		if err != nil {
+			log.Printf("Failed to fetch exposures of era %d from %s: %v", nextEra, rpcURL, err)
+		}
+	}
+
+	return Distribution{Validators: votingPowers, NextEpoch: nextEpoch}, nil
+}
+
+// fetchSubstrateEraExposures returns the backing stake of the validators elected in the given era,
+// or nothing if no exposures are stored for it.
+func fetchSubstrateEraExposures(rpcURL, head string, era int64, ss58Prefix uint16) ([]Validator, error) {
+	eraKey := make([]byte, 4)
+	binary.LittleEndian.PutUint32(eraKey, uint32(era))
+
+	var (
+		keys []string
+		err  error
+	)
+	for _, item := range []string{"ErasStakersOverview", "ErasStakers"} {
+		prefix := append(storagePrefix("Staking", item), twox64Concat(eraKey)...)
+		keys, err = fetchSubstrateKeys(rpcURL, "0x"+hex.EncodeToString(prefix), head)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s keys: %w", item, err)
+		}
+		if len(keys) > 0 {
+			break
+		}
+	}
+
+	var votingPowers []Validator
+	for start := 0; start < len(keys); start += substrateValuesBatch {
+		end := min(start+substrateValuesBatch, len(keys))
+
+		var changeSets []substrateStorageChangeSet
+		if err := jsonRPC(rpcURL, "state_queryStorageAt", []interface{}{keys[start:end], head}, &changeSets); err != nil {
+			return nil, fmt.Errorf("failed to fetch exposures: %w", err)
+		}
+
+		for _, changeSet := range changeSets {
+			for _, change := range changeSet.Changes {
+				if change[0] == nil || change[1] == nil {
+					continue
+				}
+
+				validator, err := decodeSubstrateExposure(*change[0], *change[1], ss58Prefix)
+				if err != nil {
+					return nil, err
+				}
+				votingPowers = append(votingPowers, validator)
+			}
+		}
+	}
+
+	if len(votingPowers) != len(keys) {
+		return nil, fmt.Errorf("fetched %d exposures but era %d has %d", len(votingPowers), era, len(keys))
+	}
+
+	return votingPowers, nil
+}
+
+// decodeSubstrateExposure decodes an exposure storage entry. Both the paged overview and the legacy
+// exposure start with the total backing stake as a compact integer, and the key ends with the validator's account.
+func decodeSubstrateExposure(key, value string, ss58Prefix uint16) (Validator, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+	if err != nil || len(keyBytes) < substrateAccountLen {
+		return Validator{}, fmt.Errorf("invalid exposure key %s", key)
+	}
+
+	address, err := ss58Encode(keyBytes[len(keyBytes)-substrateAccountLen:], ss58Prefix)
+	if err != nil {
+		return Validator{}, err
+	}
+
+	valueBytes, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return Validator{}, fmt.Errorf("invalid exposure of %s", address)
+	}
+
+	total, _, err := decodeCompact(valueBytes)
+	if err != nil {
+		return Validator{}, fmt.Errorf("failed to decode exposure of %s: %w", address, err)
+	}
+
+	return Validator{Address: address, VotingPower: total}, nil
+}
+
+// fetchSubstrateActiveEra returns the index of the active era, the first field of Staking.ActiveEra.
+func fetchSubstrateActiveEra(rpcURL, head string) (uint32, error) {
+	var value *string
+	key := "0x" + hex.EncodeToString(storagePrefix("Staking", "ActiveEra"))
+	if err := jsonRPC(rpcURL, "state_getStorage", []interface{}{key, head}, &value); err != nil {
+		return 0, fmt.Errorf("failed to fetch active era: %w", err)
+	}
+	if value == nil {
+		return 0, errors.New("no active era")
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(*value, "0x"))
+	if err != nil || len(data) < 4 {
+		return 0, fmt.Errorf("invalid active era %s", *value)
+	}
+
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+// fetchSubstrateKeys returns all storage keys with the given prefix, following pagination.
+func fetchSubstrateKeys(rpcURL, prefix, head string) ([]string, error) {
+	var (
+		keys     []string
+		startKey = prefix
+	)
+
+	for {
+		var page []string
+		if err := jsonRPC(rpcURL, "state_getKeysPaged", []interface{}{prefix, substrateKeysPage, startKey, head}, &page); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, page...)
+		if len(page) < substrateKeysPage {
+			return keys, nil
+		}
+		startKey = page[len(page)-1]
+	}
+}
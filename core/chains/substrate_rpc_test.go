@@ -0,0 +1,191 @@
+package chains
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	substrateTestHead = "0x6e0b3f3a5b1c5d2e4f7081929aa3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5"
+	alicePubKey       = "d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+	bobPubKey         = "8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48"
+)
+
+// substrateExposureKey returns the storage key of a validator's exposure in a Staking double map keyed by era and account.
+func substrateExposureKey(item string, era uint32, pubKey string) string {
+	eraKey := make([]byte, 4)
+	binary.LittleEndian.PutUint32(eraKey, era)
+	account, _ := hex.DecodeString(pubKey)
+
+	key := append(storagePrefix("Staking", item), twox64Concat(eraKey)...)
+	return "0x" + hex.EncodeToString(append(key, twox64Concat(account)...))
+}
+
+// substrateStandIn answers the storage methods of a Substrate node from a fixed storage map, at substrateTestHead only.
+func substrateStandIn(t *testing.T, storage map[string]string) string {
+	var keys []string
+	for k := range storage {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	atHead := func(hash string) error {
+		if hash != substrateTestHead {
+			t.Errorf("storage read at %s, want the finalized head", hash)
+		}
+		return nil
+	}
+
+	server := newJSONRPCStandIn(t, map[string]rpcHandler{
+		"chain_getFinalizedHead": func(json.RawMessage) (interface{}, error) {
+			return substrateTestHead, nil
+		},
+		"system_properties": func(json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{"ss58Format": 0, "tokenSymbol": "DOT"}, nil
+		},
+		"state_getStorage": func(raw json.RawMessage) (interface{}, error) {
+			var params []string
+			_ = json.Unmarshal(raw, &params)
+			_ = atHead(params[1])
+			if v, ok := storage[params[0]]; ok {
+				return v, nil
+			}
+			return nil, nil
+		},
+		"state_getKeysPaged": func(raw json.RawMessage) (interface{}, error) {
+			var (
+				prefix, startKey, hash string
+				count                  int
+			)
+			_ = json.Unmarshal(raw, &[]interface{}{&prefix, &count, &startKey, &hash})
+			_ = atHead(hash)
+
+			page := []string{}
+			for _, k := range keys {
+				if strings.HasPrefix(k, prefix) && k > startKey && len(page) < count {
+					page = append(page, k)
+				}
+			}
+			return page, nil
+		},
+		"state_queryStorageAt": func(raw json.RawMessage) (interface{}, error) {
+			var (
+				queried []string
+				hash    string
+			)
+			_ = json.Unmarshal(raw, &[]interface{}{&queried, &hash})
+			_ = atHead(hash)
+
+			changes := [][2]*string{}
+			for _, k := range queried {
+				k := k
+				if v, ok := storage[k]; ok {
+					changes = append(changes, [2]*string{&k, &v})
+				}
+			}
+			return []substrateStorageChangeSet{{Block: hash, Changes: changes}}, nil
+		},
+	})
+
+	return server.URL
+}
+
+func TestFetchSubstrateExposures(t *testing.T) {
+	// Active era 1000 with index 1000 and no start time yet.
+	activeEraKey := "0x" + hex.EncodeToString(storagePrefix("Staking", "ActiveEra"))
+	activeEra := "0xe803000000"
+
+	tests := []struct {
+		name      string
+		storage   map[string]string
+		era       int64
+		want      map[string]string
+		wantNext  map[string]string
+		wantError bool
+	}{
+		{
+			name: "paged exposures",
+			storage: map[string]string{
+				activeEraKey: activeEra,
+				// PagedExposureMetadata: total 10^20, own 100, one nominator on one page.
+				substrateExposureKey("ErasStakersOverview", 1000, alicePubKey): "0x17000010632d5ec76b05910101000000" + "01000000",
+				// Total 1000000, own 1000000, no nominators.
+				substrateExposureKey("ErasStakersOverview", 1000, bobPubKey): "0x02093d0002093d000000000000000000",
+				// The legacy exposures of the era are no longer written and must be ignored.
+				substrateExposureKey("ErasStakers", 1000, bobPubKey):         "0x0500" + "00",
+				substrateExposureKey("ErasStakersOverview", 1001, bobPubKey): "0x9101910100000000" + "00000000",
+			},
+			era: -1,
+			want: map[string]string{
+				"15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5": "100000000000000000000",
+				"14E5nqKAp3oAJcmzgZhUD2RcptBeUBScxKHgJKU4HPNcKVf3": "1000000",
+			},
+			wantNext: map[string]string{"14E5nqKAp3oAJcmzgZhUD2RcptBeUBScxKHgJKU4HPNcKVf3": "100"},
+		},
+		{
+			name: "legacy exposures",
+			storage: map[string]string{
+				activeEraKey: activeEra,
+				// Exposure: total 1000000, own 100, one nominator backing 999900.
+				substrateExposureKey("ErasStakers", 900, alicePubKey): "0x02093d009101" + "04" + bobPubKey + "72093d00",
+				// Total 100, own 100, no nominators.
+				substrateExposureKey("ErasStakers", 900, bobPubKey): "0x9101910100",
+			},
+			era: 900,
+			want: map[string]string{
+				"15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5": "1000000",
+				"14E5nqKAp3oAJcmzgZhUD2RcptBeUBScxKHgJKU4HPNcKVf3": "100",
+			},
+		},
+		{
+			name:      "no exposures",
+			storage:   map[string]string{activeEraKey: activeEra},
+			era:       -1,
+			wantError: true,
+		},
+		{
+			name: "invalid exposure",
+			storage: map[string]string{
+				substrateExposureKey("ErasStakers", 900, alicePubKey): "0x03",
+			},
+			era:       900,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dist, err := FetchSubstrateExposures(substrateStandIn(t, tt.storage), tt.era)
+			if tt.wantError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check := func(set string, validators []Validator, want map[string]string) {
+				got := make(map[string]string)
+				for _, v := range validators {
+					got[v.Address] = v.VotingPower.String()
+				}
+				if len(got) != len(want) {
+					t.Errorf("%s = %v, want %v", set, got, want)
+				}
+				for address, stake := range want {
+					if got[address] != stake {
+						t.Errorf("%s stake of %s = %s, want %s", set, address, got[address], stake)
+					}
+				}
+			}
+			check("validators", dist.Validators, tt.want)
+			check("next epoch", dist.NextEpoch, tt.wantNext)
+		})
+	}
+}
@@ -10,29 +10,28 @@ import (
 	"log"
 	"math/big"
 	"net/http"
-	"sort"
 	"strconv"
 	"time"
-
-	utils "github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
 )
 
 type SuiResponse struct {
 	Result struct {
 		ActiveValidators []struct {
+			SuiAddress  string `json:"suiAddress"`
 			VotingPower string `json:"votingPower"`
+			NetAddress  string `json:"netAddress"`
 		} `json:"activeValidators"`
 	} `json:"result"`
 }
 
 type rawBody struct {
-	JSONRPC string        `json:"jsonrpc"`
-	ID      int           `json:"id"`
-	Method  string        `json:"method"`
-	Params  []interface{} `json:"params"`
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
 }
 
-func Sui() (int, error) {
+func Sui() (Distribution, error) {
 	request := rawBody{
 		JSONRPC: "2.0",
 		ID:      1,
@@ -45,14 +44,16 @@ func Sui() (int, error) {
 	return fetchDataSUI("sui", baseURL, request)
 }
 
-// fetchDataSUI returns the nakamoto coefficient value for SUI by fetching sui validator voting powers
-// and calculating NC value from the data.
-func fetchDataSUI(chainName string, url string, request rawBody) (int, error) {
-	var votingPowers []big.Int
+// fetchDataSUI returns the voting power distribution for SUI by fetching sui validator voting powers.
+func fetchDataSUI(chainName string, url string, request rawBody) (Distribution, error) {
+	var (
+		votingPowers     []Validator
+		networkAddresses = make(map[string]string)
+	)
 
 	response, err := fetchData(url, request)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch data for %s: %w", chainName, err)
+		return Distribution{}, fmt.Errorf("failed to fetch data for %s: %w", chainName, err)
 	}
 
 	// Loop through the validators voting powers.
@@ -64,21 +65,16 @@ func fetchDataSUI(chainName string, url string, request rawBody) (int, error) {
 			log.Println(err)
 		}
 
-		votingPowers = append(votingPowers, *big.NewInt(votingPower))
+		votingPowers = append(votingPowers, Validator{
+			Address:     ele.SuiAddress,
+			VotingPower: big.NewInt(votingPower),
+		})
+		if host := multiaddrHost(ele.NetAddress); host != "" {
+			networkAddresses[ele.SuiAddress] = host
+		}
 	}
 
-	sort.Slice(votingPowers, func(i, j int) bool {
-		return votingPowers[i].Cmp(&votingPowers[j]) > 0
-	})
-
-	totalVotingPower := utils.CalculateTotalVotingPowerBigNums(votingPowers)
-	fmt.Printf("Total voting power for %s: %s\n", chainName, new(big.Float).SetInt(totalVotingPower).String())
-
-	// Now we're ready to calculate the nakomoto coefficient.
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums(totalVotingPower, votingPowers)
-	fmt.Printf("The Nakamoto coefficient for %s is %d\n", chainName, nakamotoCoefficient)
-
-	return nakamotoCoefficient, nil
+	return Distribution{Validators: votingPowers, NetworkAddresses: networkAddresses}, nil
 }
 
 func fetchData(url string, request rawBody) (SuiResponse, error) {
@@ -9,16 +9,20 @@ import (
 	"log"
 	"math/big"
 	"net/http"
-	"sort"
 	"time"
-
-	"github.com/xenowits/nakamoto-coefficient-calculator/core/utils"
 )
 
 type ThorchainResponse []struct {
-	NodeAddress string `json:"node_address"`
-	Bond        string `json:"total_bond"`
-	Status      string `json:"status"`
+	NodeAddress         string `json:"node_address"`
+	NodeOperatorAddress string `json:"node_operator_address"`
+	Bond                string `json:"total_bond"`
+	Status              string `json:"status"`
+	BondProviders       struct {
+		Providers []struct {
+			BondAddress string `json:"bond_address"`
+			Bond        string `json:"bond"`
+		} `json:"providers"`
+	} `json:"bond_providers"`
 }
 
 type ThorchainErrorResponse struct {
@@ -27,8 +31,8 @@ type ThorchainErrorResponse struct {
 	Error   string `json:"error"`
 }
 
-func Thorchain() (int, error) {
-	votingPowers := make([]big.Int, 0, 1000)
+func Thorchain() (Distribution, error) {
+	votingPowers := make([]Validator, 0, 1000)
 	url := fmt.Sprintf("https://thornode.ninerealms.com/thorchain/nodes")
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunc()
@@ -36,54 +40,102 @@ func Thorchain() (int, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		log.Println(err)
-		return 0, errors.New("create get request for thorchain")
+		return Distribution{}, errors.New("create get request for thorchain")
 	}
 
 	resp, err := new(http.Client).Do(req)
 	if err != nil {
 		log.Println(err)
-		return 0, errors.New("get request unsuccessful")
+		return Distribution{}, errors.New("get request unsuccessful")
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	var response ThorchainResponse
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return 0, err
+		return Distribution{}, err
 	}
 
 	// loop through the validators voting powers
+	var (
+		operators       = make(map[string][]string)
+		operatorOrder   []string
+		providerBonds   = make(map[string]*big.Int)
+		providerOrder   []string
+		addProviderBond = func(provider string, bond *big.Int) {
+			if _, ok := providerBonds[provider]; !ok {
+				providerBonds[provider] = new(big.Int)
+				providerOrder = append(providerOrder, provider)
+			}
+			providerBonds[provider].Add(providerBonds[provider], bond)
+		}
+	)
 	for _, ele := range response {
+		// Assuming we calculate only for stakers with "active" stakers
+		// And discard "disabled" and "standby" stakers
+		if ele.Status != "Active" {
+			continue
+		}
+
 		n, ok := new(big.Int).SetString(ele.Bond, 10)
 		if !ok {
-			log.Fatalln("Couldn't parse string", ele.Bond)
-		} else if ele.Status == "Active" {
-			// Assuming we calculate only for stakers with "active" stakers
-			// And discard "disabled" and "standby" stakers
-			votingPowers = append(votingPowers, *n)
+			return Distribution{}, fmt.Errorf("failed to parse bond %q of node %s", ele.Bond, ele.NodeAddress)
+		}
+		votingPowers = append(votingPowers, Validator{Address: ele.NodeAddress, VotingPower: n})
+
+		operator := ele.NodeOperatorAddress
+		if operator == "" {
+			operator = ele.NodeAddress
+		}
+		if _, ok := operators[operator]; !ok {
+			operatorOrder = append(operatorOrder, operator)
+		}
+		operators[operator] = append(operators[operator], ele.NodeAddress)
+
+		// Bond not attributed to a provider is the operator's own.
+		unattributed := new(big.Int).Set(n)
+		for _, provider := range ele.BondProviders.Providers {
+			bond, ok := new(big.Int).SetString(provider.Bond, 10)
+			if !ok {
+				return Distribution{}, fmt.Errorf("failed to parse bond %q of provider %s to node %s", provider.Bond, provider.BondAddress, ele.NodeAddress)
+			}
+			addProviderBond(provider.BondAddress, bond)
+			unattributed.Sub(unattributed, bond)
+		}
+		if unattributed.Sign() > 0 {
+			addProviderBond(operator, unattributed)
 		}
 	}
 
-	// need to sort the powers in descending order since they are in random order
-	sort.Slice(votingPowers, func(i, j int) bool {
-		res := (&votingPowers[i]).Cmp(&votingPowers[j])
-		if res == 1 {
-			return true
+	// Node operators run their nodes however many bond providers fund them, so nodes are grouped by operator.
+	var groups []EntityGroup
+	for _, operator := range operatorOrder {
+		nodes := operators[operator]
+		if len(nodes) < 2 {
+			continue
 		}
-		return false
-	})
 
-	totalVotingPower := utils.CalculateTotalVotingPowerBigNums(votingPowers)
-	fmt.Println("Total voting power:", totalVotingPower)
+		groups = append(groups, EntityGroup{
+			Entity:     operator,
+			Validators: nodes,
+			Evidence:   []string{fmt.Sprintf("node_operator_address=%s shared by %d validators", operator, len(nodes))},
+		})
+	}
 
-	// now we're ready to calculate the nakomoto coefficient
-	nakamotoCoefficient := utils.CalcNakamotoCoefficientBigNums(totalVotingPower, votingPowers)
-	fmt.Println("The Nakamoto coefficient for thorchain is", nakamotoCoefficient)
+	// Bond providers can unbond from the nodes they fund, so the bond of each provider is reported as an alternative.
+	var providers []Validator
+	for _, provider := range providerOrder {
+		providers = append(providers, Validator{Address: provider, VotingPower: providerBonds[provider]})
+	}
 
-	return nakamotoCoefficient, nil
+	return Distribution{
+		Validators:   votingPowers,
+		EntityGroups: groups,
+		Alternatives: map[string]Distribution{"bond_provider": {Validators: providers}},
+	}, nil
 }
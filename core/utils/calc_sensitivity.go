@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Sensitivity describes how far a voting power distribution is from changing its Nakamoto coefficient.
+// Validators are referenced by their index in the sorted voting powers.
+type Sensitivity struct {
+	Coefficient int
+	// Threshold is the voting power a coalition must exceed to control the chain.
+	Threshold *big.Int
+	// Margin is the voting power of the smallest coalition above the threshold.
+	Margin *big.Int
+	// RaiseStake is the least voting power that must leave the largest validators (RaiseFrom) for the
+	// coefficient to grow by one, assuming it moves to the smallest validators. It is nil when the coefficient
+	// cannot grow, because the validators are too few to keep the coalition below the threshold, or when no
+	// coalition controls the chain.
+	RaiseStake *big.Int
+	RaiseFrom  []int
+	// LowerStake is the least voting power that must move into the largest validators (LowerTo) for the
+	// coefficient to shrink by one. It is nil when the coefficient is already one.
+	LowerStake *big.Int
+	LowerTo    []int
+}
+
+// CalcNakamotoSensitivity calculates the Nakamoto coefficient of votingPowers, which must be sorted in
+// descending order, along with the voting power needed to move it by one in either direction. A coalition
+// controls the chain when it exceeds thresholdPercent of the total voting power or, if inclusive, when it
+// reaches that share rounded down.
+func CalcNakamotoSensitivity(totalVotingPower *big.Int, votingPowers []*big.Int, thresholdPercent float64, inclusive bool) Sensitivity {
+	// Voting powers are integers, so exceeding the exact threshold is the same as exceeding its floor,
+	// and reaching the floor is the same as exceeding the floor minus one.
+	ratio := new(big.Rat).SetFloat64(thresholdPercent / 100)
+	thresholdRat := new(big.Rat).Mul(new(big.Rat).SetInt(totalVotingPower), ratio)
+	threshold := new(big.Int).Quo(thresholdRat.Num(), thresholdRat.Denom())
+	if inclusive {
+		threshold.Sub(threshold, big.NewInt(1))
+	}
+
+	res := Sensitivity{Threshold: threshold}
+
+	cumulative := big.NewInt(0)
+	for _, vp := range votingPowers {
+		cumulative.Add(cumulative, vp)
+		res.Coefficient++
+		if cumulative.Cmp(threshold) > 0 {
+			break
+		}
+	}
+
+	res.Margin = new(big.Int).Sub(cumulative, threshold)
+	if res.Margin.Sign() < 0 {
+		// The whole set does not exceed the threshold, so nothing can move the coefficient.
+		res.Margin.SetInt64(0)
+		return res
+	}
+
+	k := res.Coefficient
+
+	// To lower the coefficient, the k-1 largest validators must exceed the threshold on their own.
+	if k > 1 {
+		prev := new(big.Int).Sub(cumulative, votingPowers[k-1])
+		res.LowerStake = new(big.Int).Sub(threshold, prev)
+		res.LowerStake.Add(res.LowerStake, big.NewInt(1))
+		for i := 0; i < k-1; i++ {
+			res.LowerTo = append(res.LowerTo, i)
+		}
+	}
+
+	// To raise the coefficient, the k largest validators must no longer exceed the threshold, wherever the
+	// stake taken from them goes. Cap the largest validators at the highest level that achieves this.
+	level := raiseLevel(threshold, votingPowers, k)
+	if level == nil {
+		return res
+	}
+	res.RaiseStake = big.NewInt(0)
+	for i, vp := range votingPowers {
+		if vp.Cmp(level) <= 0 {
+			break
+		}
+		res.RaiseStake.Add(res.RaiseStake, new(big.Int).Sub(vp, level))
+		res.RaiseFrom = append(res.RaiseFrom, i)
+	}
+
+	return res
+}
+
+// raiseLevel returns the highest level at which the voting powers can be capped, with the capped stake spread
+// over the smallest validators without lifting any of them above the level, so that the k largest validators
+// no longer exceed the threshold. It returns nil if no level achieves this, for example when the validators are
+// too few to hold the total voting power below the threshold.
+func raiseLevel(threshold *big.Int, votingPowers []*big.Int, k int) *big.Int {
+	n := len(votingPowers)
+
+	// prefix[i] is the voting power of the i largest validators.
+	prefix := make([]*big.Int, n+1)
+	prefix[0] = big.NewInt(0)
+	for i, vp := range votingPowers {
+		prefix[i+1] = new(big.Int).Add(prefix[i], vp)
+	}
+	total := prefix[n]
+
+	// Below the average voting power the capped stake cannot be spread without lifting validators above the level.
+	lo := new(big.Int).Add(total, big.NewInt(int64(n-1)))
+	lo.Quo(lo, big.NewInt(int64(n)))
+	if !raiseFeasible(threshold, votingPowers, prefix, k, lo) {
+		return nil
+	}
+
+	// The feasible levels form a range starting at lo, and the largest voting power is not part of it.
+	hi := new(big.Int).Set(votingPowers[0])
+	for new(big.Int).Sub(hi, lo).Cmp(big.NewInt(1)) > 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if raiseFeasible(threshold, votingPowers, prefix, k, mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo
+}
+
+// raiseFeasible reports whether capping the voting powers at level, and spreading the capped stake evenly over
+// the smallest validators, leaves the k largest validators at or below the threshold. The level must be at least
+// the average voting power, so the smallest validators can absorb the capped stake.
+func raiseFeasible(threshold *big.Int, votingPowers []*big.Int, prefix []*big.Int, k int, level *big.Int) bool {
+	n := len(votingPowers)
+
+	// The first capped validators exceed the level.
+	capped := sort.Search(n, func(i int) bool { return votingPowers[i].Cmp(level) <= 0 })
+
+	// cappedAt returns the voting power of validator i after capping.
+	cappedAt := func(i int) *big.Int {
+		if i < capped {
+			return level
+		}
+		return votingPowers[i]
+	}
+	// sumCapped returns the capped voting power of validators i to j-1.
+	sumCapped := func(i, j int) *big.Int {
+		if i >= j {
+			return big.NewInt(0)
+		}
+		sum := new(big.Int).Sub(prefix[j], prefix[max(i, capped)])
+		if i < capped {
+			sum.Add(sum, new(big.Int).Mul(level, big.NewInt(int64(min(j, capped)-i))))
+		}
+		return sum
+	}
+
+	moved := sumCapped(0, capped)
+	moved.Sub(prefix[capped], moved)
+
+	// Fill the m smallest validators to a common water level w = (moved + their capped voting power) / m,
+	// choosing the least m for which the next validator is not below it.
+	fills := func(m int) bool {
+		if m == n {
+			return true
+		}
+		lhs := new(big.Int).Add(moved, sumCapped(n-m, n))
+		return lhs.Cmp(new(big.Int).Mul(cappedAt(n-m-1), big.NewInt(int64(m)))) <= 0
+	}
+	m := sort.Search(n, func(m int) bool { return m > 0 && fills(m) })
+	water := new(big.Int).Add(moved, sumCapped(n-m, n))
+
+	// The k largest validators after filling, scaled by m to keep the water level exact.
+	top := new(big.Int).Mul(sumCapped(0, min(k, n-m)), big.NewInt(int64(m)))
+	if raised := k - (n - m); raised > 0 {
+		top.Add(top, new(big.Int).Mul(water, big.NewInt(int64(raised))))
+	}
+
+	return top.Cmp(new(big.Int).Mul(threshold, big.NewInt(int64(m)))) <= 0
+}
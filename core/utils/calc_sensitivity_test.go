@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCalcNakamotoSensitivity(t *testing.T) {
+	tests := []struct {
+		name         string
+		votingPowers []int64
+		total        int64   // the sum of the voting powers if zero
+		threshold    float64 // THRESHOLD_PERCENT if zero
+		inclusive    bool
+		coefficient  int
+		raiseStake   int64 // -1 if the coefficient cannot grow
+		raiseFrom    int
+		lowerStake   int64 // -1 if the coefficient cannot shrink
+	}{
+		{name: "equal five", votingPowers: []int64{20, 20, 20, 20, 20}, coefficient: 2, raiseStake: -1, lowerStake: 14},
+		{name: "equal ten", votingPowers: []int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, coefficient: 4, raiseStake: -1, lowerStake: 4},
+		{name: "single large", votingPowers: []int64{50, 10, 10, 10, 10, 10}, coefficient: 1, raiseStake: 17, raiseFrom: 1, lowerStake: -1},
+		// Capping the largest at 25 would move 5 to the others, lifting the second largest above 8.
+		{name: "spread lifts others", votingPowers: []int64{30, 8, 8, 8, 8, 8, 8, 8, 8, 6}, coefficient: 2, raiseStake: 6, raiseFrom: 1, lowerStake: 4},
+		// Reaching a third of 90 is enough when the threshold is inclusive, but 30 does not exceed it.
+		{name: "exactly a third", votingPowers: []int64{30, 20, 20, 20}, threshold: 100.0 / 3, coefficient: 2, raiseStake: -1, lowerStake: 1},
+		{name: "exactly a third inclusive", votingPowers: []int64{30, 20, 20, 20}, threshold: 100.0 / 3, inclusive: true, coefficient: 1, raiseStake: 1, raiseFrom: 1, lowerStake: -1},
+		// The validators hold less than the threshold of the total, as when inactive stake is counted.
+		{name: "unreachable", votingPowers: []int64{20, 20, 20}, total: 200, coefficient: 3, raiseStake: -1, lowerStake: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				votingPowers []*big.Int
+				total        = new(big.Int)
+			)
+			for _, vp := range tt.votingPowers {
+				votingPowers = append(votingPowers, big.NewInt(vp))
+				total.Add(total, big.NewInt(vp))
+			}
+
+			if tt.total != 0 {
+				total = big.NewInt(tt.total)
+			}
+			threshold := tt.threshold
+			if threshold == 0 {
+				threshold = THRESHOLD_PERCENT
+			}
+
+			res := CalcNakamotoSensitivity(total, votingPowers, threshold, tt.inclusive)
+			if res.Coefficient != tt.coefficient {
+				t.Fatalf("coefficient = %d, want %d", res.Coefficient, tt.coefficient)
+			}
+
+			switch {
+			case tt.raiseStake < 0 && res.RaiseStake != nil:
+				t.Errorf("raise stake = %s, want none", res.RaiseStake)
+			case tt.raiseStake >= 0 && (res.RaiseStake == nil || res.RaiseStake.Int64() != tt.raiseStake):
+				t.Errorf("raise stake = %v, want %d", res.RaiseStake, tt.raiseStake)
+			case len(res.RaiseFrom) != tt.raiseFrom:
+				t.Errorf("raise from %d validators, want %d", len(res.RaiseFrom), tt.raiseFrom)
+			}
+
+			switch {
+			case tt.lowerStake < 0 && res.LowerStake != nil:
+				t.Errorf("lower stake = %s, want none", res.LowerStake)
+			case tt.lowerStake >= 0 && (res.LowerStake == nil || res.LowerStake.Int64() != tt.lowerStake):
+				t.Errorf("lower stake = %v, want %d", res.LowerStake, tt.lowerStake)
+			}
+		})
+	}
+}
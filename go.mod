@@ -23,5 +23,5 @@ require (
 
 require (
 	github.com/gin-gonic/gin v1.7.7
-	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97 // indirect
+	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
 )
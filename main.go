@@ -1,24 +1,115 @@
 package main
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/xenowits/nakamoto-coefficient-calculator/core/chains"
 	"log"
+	"math/big"
+	"os"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 type JsonResponse struct {
-	ChainName     string `json:"chain_name"`
-	ChainToken    string `json:"chain_token"`
-	NakaCoPrevVal int    `json:"naka_co_prev_val"`
-	NakaCoCurrVal int    `json:"naka_co_curr_val"`
-	Change        int    `json:"naka_co_change_val"`
+	ChainName     string          `json:"chain_name"`
+	ChainToken    string          `json:"chain_token"`
+	NakaCoPrevVal int             `json:"naka_co_prev_val"`
+	NakaCoCurrVal int             `json:"naka_co_curr_val"`
+	Change        int             `json:"naka_co_change_val"`
+	NakaCoEntity  int             `json:"naka_co_entity_val,omitempty"`
+	NakaCoCtrl    int             `json:"naka_co_controller_val,omitempty"`
+	NakaCoCand    int             `json:"naka_co_candidate_val,omitempty"`
+	NakaCoNext    int             `json:"naka_co_next_epoch_val,omitempty"`
+	NakaCoMetrics map[string]int  `json:"naka_co_metrics,omitempty"`
+	NakaCoCapture []float64       `json:"committee_capture_probabilities,omitempty"`
+	EntityGroups  []JsonEntity    `json:"entity_groups,omitempty"`
+	EntityCount   int             `json:"entity_group_count,omitempty"`
+	Sensitivity   JsonSensitivity `json:"naka_co_sensitivity"`
+	Warnings      []JsonWarning   `json:"warnings,omitempty"`
+	// ValidatorNames are the known names of the validators listed in the response.
+	ValidatorNames map[string]string `json:"validator_names,omitempty"`
+}
+
+const (
+	// maxListedValidators caps the validators listed per entity group and sensitivity, since chains
+	// such as Ethereum have hundreds of thousands of validators.
+	maxListedValidators = 100
+	// maxListedEntityGroups caps the entity groups listed per chain, the largest first,
+	// since Ethereum validators fall into tens of thousands of groups.
+	maxListedEntityGroups = 100
+)
+
+// JsonEntity is a group of validators detected to be run by the same entity.
+// At most maxListedValidators of them are listed.
+type JsonEntity struct {
+	Entity         string   `json:"entity"`
+	ValidatorCount int      `json:"validator_count"`
+	Validators     []string `json:"validators"`
+	Evidence       []string `json:"evidence"`
+}
+
+// JsonSensitivity reports how much voting power must shift to move the coefficient by one.
+// Voting powers are encoded as strings since they may not fit into a JSON number.
+// At most maxListedValidators validators are listed, the largest first.
+type JsonSensitivity struct {
+	Coalition     []string `json:"coalition"`
+	Margin        string   `json:"margin"`
+	MarginPercent float64  `json:"margin_percent"`
+	RaiseStake    string   `json:"raise_stake,omitempty"`
+	RaiseFrom     []string `json:"raise_from,omitempty"`
+	LowerStake    string   `json:"lower_stake,omitempty"`
+	LowerTo       []string `json:"lower_to,omitempty"`
+}
+
+// JsonWarning flags a validator at risk of dropping out of consensus.
+type JsonWarning struct {
+	Validator string `json:"validator"`
+	Message   string `json:"message"`
+}
+
+// JsonCollusionRequest asks for coefficients recomputed assuming the given groups collude.
+// All chains are recomputed when Chains is empty.
+type JsonCollusionRequest struct {
+	Chains []string                `json:"chains"`
+	Groups []chains.CollusionGroup `json:"groups"`
+}
+
+// JsonCollusionResponse is the coefficient of a chain recomputed for a JsonCollusionRequest.
+type JsonCollusionResponse struct {
+	ChainName       string         `json:"chain_name"`
+	ChainToken      string         `json:"chain_token"`
+	NakaCoCurrVal   int            `json:"naka_co_curr_val"`
+	NakaCoCollusion int            `json:"naka_co_collusion_val"`
+	Matched         map[string]int `json:"matched_validators,omitempty"`
+	Error           string         `json:"error,omitempty"`
 }
 
 func main() {
+	cmd, args := "run", os.Args[1:]
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "run":
+		run()
+	case "collude":
+		if err := collude(args); err != nil {
+			log.Fatalln(err)
+		}
+	case "backfill":
+		if err := backfill(args); err != nil {
+			log.Fatalln(err)
+		}
+	default:
+		log.Fatalf("unknown command %q, expected one of: run, collude, backfill", cmd)
+	}
+}
+
+// run serves the coefficients of all chains and refreshes them periodically.
+func run() {
 	var mu sync.Mutex
 	chainState := chains.NewState()
 
@@ -37,8 +128,6 @@ func main() {
 				mu.Lock()
 				chainState = newState
 				mu.Unlock()
-
-				fmt.Println(chainState)
 			case <-quit:
 				ticker.Stop()
 				return
@@ -46,28 +135,83 @@ func main() {
 		}
 	}(chainState)
 
+	getState := func() chains.ChainState {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return chainState
+	}
+
 	// Run server.
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
 	r.GET("/naka-coeffs", func(c *gin.Context) {
-		coefficients := getListOfCoefficients(chainState)
+		coefficients := getListOfCoefficients(getState())
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.JSON(200, gin.H{
 			"coefficients": coefficients,
 		})
 	})
+	r.POST("/naka-coeffs/collusion", func(c *gin.Context) {
+		var req JsonCollusionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.JSON(200, gin.H{
+			"coefficients": getCollusionCoefficients(getState(), req),
+		})
+	})
 	r.Run(":8080") // listen and serve on 0.0.0.0:8080 (for windows "localhost:8080")
 }
 
 func getListOfCoefficients(state chains.ChainState) []JsonResponse {
 	var coeffs []JsonResponse
 	for token, chain := range state {
+		var entities []JsonEntity
+		for _, group := range chain.EntityGroups {
+			entities = append(entities, JsonEntity{
+				Entity:         group.Entity,
+				ValidatorCount: len(group.Validators),
+				Validators:     truncate(group.Validators),
+				Evidence:       group.Evidence,
+			})
+		}
+		sort.SliceStable(entities, func(i, j int) bool { return entities[i].ValidatorCount > entities[j].ValidatorCount })
+		if len(entities) > maxListedEntityGroups {
+			entities = entities[:maxListedEntityGroups]
+		}
+
+		sensitivity := newJsonSensitivity(chain.Sensitivity)
+
+		var (
+			warnings         []JsonWarning
+			warnedValidators []string
+		)
+		for _, warning := range chain.Distribution.Warnings {
+			warnings = append(warnings, JsonWarning{Validator: warning.Validator, Message: warning.Message})
+			warnedValidators = append(warnedValidators, warning.Validator)
+		}
+
 		coeffs = append(coeffs, JsonResponse{
-			ChainName:     token.ChainName(),
-			ChainToken:    string(token),
-			NakaCoPrevVal: chain.PrevNCVal,
-			NakaCoCurrVal: chain.CurrNCVal,
-			Change:        chain.CurrNCVal - chain.PrevNCVal,
+			ChainName:      token.ChainName(),
+			ChainToken:     string(token),
+			NakaCoPrevVal:  chain.PrevNCVal,
+			NakaCoCurrVal:  chain.CurrNCVal,
+			Change:         chain.CurrNCVal - chain.PrevNCVal,
+			NakaCoEntity:   chain.EntityNCVal,
+			NakaCoCtrl:     chain.ControllerNCVal,
+			NakaCoCand:     chain.CandidateNCVal,
+			NakaCoNext:     chain.NextEpochNCVal,
+			NakaCoMetrics:  chain.Metrics,
+			NakaCoCapture:  chain.CaptureProbabilities,
+			EntityGroups:   entities,
+			EntityCount:    len(chain.EntityGroups),
+			Sensitivity:    sensitivity,
+			Warnings:       warnings,
+			ValidatorNames: validatorNames(chain.Distribution, sensitivity.Coalition, sensitivity.RaiseFrom, sensitivity.LowerTo, warnedValidators),
 		})
 	}
 
@@ -81,3 +225,100 @@ func getListOfCoefficients(state chains.ChainState) []JsonResponse {
 
 	return coeffs
 }
+
+func getCollusionCoefficients(state chains.ChainState, req JsonCollusionRequest) []JsonCollusionResponse {
+	tokens := req.Chains
+	if len(tokens) == 0 {
+		for token := range state {
+			tokens = append(tokens, string(token))
+		}
+		sort.Strings(tokens)
+	}
+
+	var coeffs []JsonCollusionResponse
+	for _, t := range tokens {
+		token := chains.Token(strings.ToUpper(t))
+		res := JsonCollusionResponse{
+			ChainName:  token.ChainName(),
+			ChainToken: string(token),
+		}
+
+		chain, ok := state[token]
+		if !ok {
+			res.Error = "no coefficient available for chain"
+			coeffs = append(coeffs, res)
+			continue
+		}
+
+		collusion, err := chain.Collude(req.Groups)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		res.NakaCoCurrVal = chain.CurrNCVal
+		res.NakaCoCollusion = collusion.CollusionNCVal
+		res.Matched = collusion.Matched
+
+		coeffs = append(coeffs, res)
+	}
+
+	return coeffs
+}
+
+func newJsonSensitivity(s chains.Sensitivity) JsonSensitivity {
+	res := JsonSensitivity{
+		Coalition:     truncate(s.Coalition),
+		Margin:        bigString(s.Margin),
+		MarginPercent: s.MarginPercent,
+		RaiseFrom:     truncate(s.RaiseFrom),
+		LowerTo:       truncate(s.LowerTo),
+	}
+	if s.RaiseStake != nil {
+		res.RaiseStake = s.RaiseStake.String()
+	}
+	if s.LowerStake != nil {
+		res.LowerStake = s.LowerStake.String()
+	}
+
+	return res
+}
+
+func bigString(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+
+	return n.String()
+}
+
+// validatorNames returns the names of the given validators that have one.
+func validatorNames(dist chains.Distribution, lists ...[]string) map[string]string {
+	known := make(map[string]string)
+	for _, v := range dist.Validators {
+		if v.Name != "" {
+			known[v.Address] = v.Name
+		}
+	}
+	if len(known) == 0 {
+		return nil
+	}
+
+	names := make(map[string]string)
+	for _, list := range lists {
+		for _, addr := range list {
+			if name, ok := known[addr]; ok {
+				names[addr] = name
+			}
+		}
+	}
+
+	return names
+}
+
+// truncate returns at most maxListedValidators of the given validators.
+func truncate(validators []string) []string {
+	if len(validators) > maxListedValidators {
+		return validators[:maxListedValidators]
+	}
+
+	return validators
+}